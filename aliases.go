@@ -0,0 +1,74 @@
+package main
+
+import "fmt"
+
+// Expand a username through the aliases section of the config file. An
+// alias is either another username or a list of usernames:
+//
+//	[aliases]
+//	boss = "some-long-github-handle"
+//	team = ["alice", "bob", "carol"]
+//
+// Aliases are not expanded recursively.
+func expandUsername(config Config, name string) []string {
+	switch value := config["aliases."+name].(type) {
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	default:
+		return []string{name}
+	}
+}
+
+func expandUsernames(config Config, names []string) []string {
+	var expanded []string
+	for _, name := range names {
+		expanded = append(expanded, expandUsername(config, name)...)
+	}
+
+	return expanded
+}
+
+// Drop the repeated values, keeping the first of each in order
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
+
+// Parse provider:username[@base-url] accounts, a list alias as username gives
+// one account per member
+func parseAccounts(config Config, values []string) ([]Account, error) {
+	var accounts []Account
+	for _, value := range values {
+		account, err := parseAccount(value)
+		if err != nil {
+			return nil, err
+		}
+
+		// Without a base URL the account is on the instance of the provider
+		if account.BaseURL == "" {
+			account.BaseURL = providerBaseURL(config, account.Provider)
+		}
+		account.Token = providerToken(config, account.Provider, account.BaseURL)
+		for _, username := range expandUsername(config, account.Username) {
+			member := account
+			member.Username = username
+			accounts = append(accounts, member)
+		}
+	}
+
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no accounts left after expanding aliases")
+	}
+
+	return accounts, nil
+}
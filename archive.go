@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// The local archive keeps what the user saved on purpose, unlike the cache
+// it never expires
+type Archive struct {
+	Bookmarks  map[string]Bookmark `json:"bookmarks"`
+	EventNotes map[string][]Note   `json:"event_notes"`
+	DayNotes   map[string][]Note   `json:"day_notes"`
+	Mutes      Mutes               `json:"mutes"`
+}
+
+var archive = newArchive()
+var archiveMutex sync.Mutex
+
+func newArchive() Archive {
+	return Archive{
+		Bookmarks:  make(map[string]Bookmark),
+		EventNotes: make(map[string][]Note),
+		DayNotes:   make(map[string][]Note),
+	}
+}
+
+// Directory for local data, $XDG_DATA_HOME/github-activity or
+// ~/.local/share/github-activity
+func dataDir() string {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "."
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(base, "github-activity")
+}
+
+func archivePath() string {
+	return filepath.Join(dataDir(), "archive.json")
+}
+
+// Load the archive from file, a missing file is an empty archive
+func loadArchive() error {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	file, err := os.ReadFile(archivePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	loaded := newArchive()
+	err = json.Unmarshal(file, &loaded)
+	if err != nil {
+		return err
+	}
+	archive = loaded
+
+	return nil
+}
+
+func saveArchive() error {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	file, err := json.MarshalIndent(archive, "", " ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dataDir(), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(archivePath(), file, 0644)
+}
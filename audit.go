@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// An entry of the organization or enterprise audit log
+type GithubAuditEntry struct {
+	DocumentID string `json:"_document_id"`
+	Action     string `json:"action"`
+	Actor      string `json:"actor"`
+	Repo       string `json:"repo"`
+	User       string `json:"user"`
+	Org        string `json:"org"`
+	Timestamp  int64  `json:"@timestamp"`
+}
+
+// The audit log of an organization, or of an enterprise when enterprise is
+// set, as a provider so it shares the caching, filtering and formats of
+// user events. The username it fetches is the organization or enterprise.
+type githubAuditProvider struct {
+	token      string
+	phrase     string
+	enterprise bool
+	maxPages   int
+	rateLimit  RateLimit
+}
+
+// The phrase is part of the name so different searches are cached apart
+func (p *githubAuditProvider) Name() string {
+	if p.phrase == "" {
+		return "github-audit"
+	}
+
+	return "github-audit:" + p.phrase
+}
+
+func (p *githubAuditProvider) FetchEvents(owner string) ([]json.RawMessage, error) {
+	var entries []json.RawMessage
+	err := p.FetchEventPages(owner, func(page []json.RawMessage) {
+		entries = append(entries, page...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (p *githubAuditProvider) FetchEventPages(owner string, onPage func([]json.RawMessage)) error {
+	params := url.Values{}
+	params.Set("per_page", "100")
+	if p.phrase != "" {
+		params.Set("phrase", p.phrase)
+	}
+
+	scope := "orgs"
+	if p.enterprise {
+		scope = "enterprises"
+	}
+	auditUrl := fmt.Sprintf("%s/%s/audit-log?%s", scope, url.PathEscape(owner), params.Encode())
+	for page := 0; page < p.maxPages && auditUrl != ""; page++ {
+		var entries []json.RawMessage
+		header, err := getGithubJSONWithToken(auditUrl, p.token, &entries)
+		p.rateLimit = github.ParseRateLimit(header, "X-RateLimit-")
+		if err != nil {
+			return err
+		}
+
+		onPage(entries)
+		auditUrl = github.NextPageURL(header)
+	}
+
+	return nil
+}
+
+func (p *githubAuditProvider) Normalize(raw json.RawMessage) (Event, error) {
+	var entry GithubAuditEntry
+	err := json.Unmarshal(raw, &entry)
+	if err != nil {
+		return Event{}, err
+	}
+
+	// Audit actions are "category.operation" like repo.create, the action
+	// itself is kept as the type so presets and mutes can match it
+	category, operation := entry.Action, ""
+	if i := strings.Index(entry.Action, "."); i >= 0 {
+		category, operation = entry.Action[:i], entry.Action[i+1:]
+	}
+	event := Event{
+		ID:        entry.DocumentID,
+		Provider:  p.Name(),
+		Type:      entry.Action,
+		Action:    auditAction(operation),
+		Target:    EventTarget{Kind: auditTargetKind(category)},
+		Actor:     EventActor{Login: entry.Actor},
+		CreatedAt: time.Unix(0, entry.Timestamp*int64(time.Millisecond)).UTC(),
+		Raw:       raw,
+	}
+	if entry.Repo != "" {
+		event.Repo = EventRepo{Name: entry.Repo, URL: "https://github.com/" + entry.Repo}
+	} else if entry.Org != "" {
+		event.Repo = EventRepo{Name: entry.Org, URL: "https://github.com/" + entry.Org}
+	}
+	if entry.User != "" {
+		event.Target.Title = entry.User
+	}
+
+	return event, nil
+}
+
+func (p *githubAuditProvider) RateLimitInfo() RateLimit {
+	return p.rateLimit
+}
+
+func auditAction(operation string) string {
+	switch operation {
+	case "create", "add_member", "invite_member":
+		return ActionCreated
+	case "destroy", "remove_member", "delete":
+		return ActionDeleted
+	case "add", "join":
+		return ActionJoined
+	default:
+		return ActionOther
+	}
+}
+
+func auditTargetKind(category string) string {
+	switch category {
+	case "repo", "repository_visibility_change":
+		return TargetRepository
+	case "org", "team", "members_can_create_repos":
+		return TargetMember
+	case "protected_branch":
+		return TargetBranch
+	default:
+		return TargetUnknown
+	}
+}
+
+// audit <org> --phrase ... lists the audit log of an organization
+func runAudit(args []string) {
+	flags := newFlagSet("audit")
+	phrase := flags.String("phrase", "", "audit log search phrase, e.g. action:repo.create actor:octocat")
+	enterprise := flags.Bool("enterprise", false, "read the audit log of an enterprise instead of an organization")
+	token := flags.String("token", "", "Github token of an organization owner (defaults to GITHUB_TOKEN or github.token)")
+	format := flags.String("format", "text", "output format: text or ndjson")
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	pages := flags.Int("pages", 1, "maximum number of pages of 100 entries to fetch")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		log.Fatalf("Usage: audit <org> [--enterprise] [--phrase query] [--preset name] [--format text|ndjson]")
+	}
+	if *format != "text" && *format != "ndjson" {
+		log.Fatalf("Unknown format %q, expected text or ndjson", *format)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	for _, resolveErr := range []error{
+		settings.ResolveSecret("token", "GITHUB_TOKEN", "github.token"),
+	} {
+		if resolveErr != nil {
+			log.Fatalf("Error resolving settings: %v", resolveErr)
+		}
+	}
+	applyTimeouts(settings)
+	if *token == "" {
+		log.Fatalf("The audit log needs the token of an organization owner, pass --token or set GITHUB_TOKEN")
+	}
+	preflight(*token, "audit log")
+
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+
+	applyCache(settings)
+	loadCache()
+	err = loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	provider := &githubAuditProvider{
+		token:      *token,
+		phrase:     strings.TrimSpace(*phrase),
+		enterprise: *enterprise,
+		maxPages:   *pages,
+	}
+	_, err = printUserEvents(provider, flags.Arg(0), filter, *format, false)
+	if err != nil {
+		exitIfInterrupted()
+		if isForbidden(err) {
+			log.Fatalf("Error fetching the audit log of %s: %v (the audit log needs an organization owner token with the read:audit_log scope)", flags.Arg(0), err)
+		}
+		log.Fatalf("Error fetching the audit log of %s: %v", flags.Arg(0), err)
+	}
+
+	saveCache()
+}
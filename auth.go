@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+type GithubUser struct {
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+// What Github reports about a token
+type AuthStatus struct {
+	Login     string    `json:"login"`
+	Name      string    `json:"name,omitempty"`
+	TokenType string    `json:"token_type"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt string    `json:"expires_at,omitempty"`
+	RateLimit RateLimit `json:"rate_limit"`
+}
+
+// Guess the kind of token from its prefix
+func githubTokenType(token string) string {
+	switch {
+	case strings.HasPrefix(token, "github_pat_"):
+		return "fine-grained personal access token"
+	case strings.HasPrefix(token, "ghp_"):
+		return "classic personal access token"
+	case strings.HasPrefix(token, "gho_"):
+		return "OAuth app token"
+	case strings.HasPrefix(token, "ghu_"):
+		return "Github app user token"
+	case strings.HasPrefix(token, "ghs_"):
+		return "Github app installation token"
+	default:
+		return "unknown"
+	}
+}
+
+// Send an authenticated GET request for a path of the Github API, or a URL,
+// and decode the JSON response into v, the response headers are returned
+func getGithubJSONWithToken(githubUrl string, token string, v interface{}) (http.Header, error) {
+	header, err := newGithubClient(token).Get(githubUrl, v)
+	if err == nil {
+		warnPartialResults(header)
+	}
+
+	return header, err
+}
+
+// Check the token against /user, the scopes and expiry come from headers
+func fetchAuthStatus(token string) (AuthStatus, error) {
+	var user GithubUser
+	header, err := getGithubJSONWithToken("user", token, &user)
+	if err != nil {
+		return AuthStatus{}, err
+	}
+
+	status := AuthStatus{
+		Login:     user.Login,
+		Name:      user.Name,
+		TokenType: githubTokenType(token),
+		Scopes:    []string{},
+		ExpiresAt: header.Get("GitHub-Authentication-Token-Expiration"),
+		RateLimit: github.ParseRateLimit(header, "X-RateLimit-"),
+	}
+	for _, scope := range strings.Split(header.Get("X-OAuth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			status.Scopes = append(status.Scopes, scope)
+		}
+	}
+
+	return status, nil
+}
+
+// auth status (also whoami) validates the Github token and prints who it
+// belongs to, its scopes, expiry and rate limit
+func runAuth(args []string) {
+	flags := newFlagSet("auth")
+	_ = flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) < 1 || args[0] != "status" {
+		log.Fatalf("Usage: auth status [--token token] [--format text|json] [--timeout 10s] [--deadline 2m] [--retries 3]")
+	}
+	runWhoami(args[1:])
+}
+
+func runWhoami(args []string) {
+	flags := newFlagSet("whoami")
+	token := flags.String("token", "", "Github token to check (defaults to GITHUB_TOKEN or github.token)")
+	format := flags.String("format", "text", "output format: text or json")
+	applyTimeouts := addTimeoutFlags(flags)
+	_ = flags.Parse(args)
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	// Same precedence as the other commands, so this checks the token they use
+	settings := newSettingsResolver(flags, config)
+	err = settings.ResolveSecret("token", "GITHUB_TOKEN", "github.token")
+	if err != nil {
+		log.Fatalf("Error resolving settings: %v", err)
+	}
+	if *token == "" {
+		log.Fatalf("No Github token configured, pass --token, set GITHUB_TOKEN or run config set github.token <token>")
+	}
+	applyTimeouts(settings)
+
+	status, err := fetchAuthStatus(*token)
+	if err != nil {
+		exitIfInterrupted()
+		log.Fatalf("Token rejected by Github: %v", err)
+	}
+
+	switch *format {
+	case "json":
+		err = printJSON(status)
+		if err != nil {
+			log.Fatalf("Error encoding auth status: %v", err)
+		}
+	case "text":
+		source := settings.resolved[0]
+		fmt.Printf("Login: %s\n", status.Login)
+		if status.Name != "" {
+			fmt.Printf("Name: %s\n", status.Name)
+		}
+		fmt.Printf("Token: %s from %s %s\n", maskSecret(*token), source.Source, source.Origin)
+		fmt.Printf("Token Type: %s\n", status.TokenType)
+		if len(status.Scopes) > 0 {
+			fmt.Printf("Scopes: %s\n", strings.Join(status.Scopes, ", "))
+		} else {
+			fmt.Println("Scopes: none reported (fine-grained tokens use repository permissions instead)")
+		}
+		if status.ExpiresAt != "" {
+			fmt.Printf("Expires At: %s\n", status.ExpiresAt)
+		} else {
+			fmt.Println("Expires At: never")
+		}
+		fmt.Printf("Rate Limit: %d/%d remaining, resets at %s\n", status.RateLimit.Remaining, status.RateLimit.Limit, status.RateLimit.Reset.Format(time.Kitchen))
+	default:
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+}
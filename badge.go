@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// Colors of the right half of a badge, as on shields.io
+const (
+	badgeLabelColor = "#555"
+	badgeGreen      = "#4c1"
+	badgeGrey       = "#9f9f9f"
+	badgeRed        = "#e05d44"
+)
+
+// A shields.io style badge: a grey label and a colored message
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// What the events of a badge are counted over, in words: this week for 7d
+func badgePeriod(since string) string {
+	switch since {
+	case "1d", "24h":
+		return "today"
+	case "7d", "1w":
+		return "this week"
+	case "30d":
+		return "this month"
+	case "365d", "1y":
+		return "this year"
+	}
+
+	return "since " + since
+}
+
+// The default period of a badge kind: a week of events, or every fetched
+// event for the streak
+func badgeSince(kind string) string {
+	if kind == "events" {
+		return "7d"
+	}
+
+	return ""
+}
+
+// Build the badge of a user: events counts the events since the filter
+// start, e.g. "47 events this week", streak the days in a row with activity
+// up to today, e.g. "12-day streak"
+func makeBadge(provider Provider, username string, kind string, label string, since string, filter EventFilter) (Badge, error) {
+	events, err := getEvents(provider, username)
+	if err != nil {
+		return Badge{}, err
+	}
+	events = withStoredEvents(provider, username, events)
+	events = filter.Apply(events)
+
+	badge := Badge{Label: label, Color: badgeGreen}
+	switch kind {
+	case "streak":
+		days := eventsPerDay(events, filter.Since, graphEnd(filter))
+		streak := computeStreaks(username, days, 1).Current.Days
+		badge.Message = fmt.Sprintf("%d-day streak", streak)
+		if streak == 0 {
+			badge.Message = "no streak"
+			badge.Color = badgeGrey
+		}
+	default:
+		badge.Message = fmt.Sprintf("%d %s", len(events), plural(len(events), "event"))
+		if since != "" {
+			badge.Message += " " + badgePeriod(since)
+		}
+		if len(events) == 0 {
+			badge.Color = badgeGrey
+		}
+	}
+
+	return badge, nil
+}
+
+func validateBadgeKind(kind string) error {
+	if kind != "events" && kind != "streak" {
+		return fmt.Errorf("unknown badge %q, expected events or streak", kind)
+	}
+
+	return nil
+}
+
+// The width of text in the 11px Verdana of the badges, close enough without
+// the font metrics
+func badgeTextWidth(text string) int {
+	return utf8.RuneCountInString(text)*7 + 10
+}
+
+// The flat badge SVG of shields.io
+func (b Badge) SVG() []byte {
+	labelWidth := badgeTextWidth(b.Label)
+	messageWidth := badgeTextWidth(b.Message)
+	width := labelWidth + messageWidth
+	label := html.EscapeString(b.Label)
+	message := html.EscapeString(b.Message)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"20\" role=\"img\" aria-label=\"%s: %s\">\n", width, label, message)
+	fmt.Fprintf(&out, "<title>%s: %s</title>\n", label, message)
+	out.WriteString("<linearGradient id=\"s\" x2=\"0\" y2=\"100%\"><stop offset=\"0\" stop-color=\"#bbb\" stop-opacity=\".1\"/><stop offset=\"1\" stop-opacity=\".1\"/></linearGradient>\n")
+	fmt.Fprintf(&out, "<clipPath id=\"r\"><rect width=\"%d\" height=\"20\" rx=\"3\" fill=\"#fff\"/></clipPath>\n", width)
+	fmt.Fprintf(&out, "<g clip-path=\"url(#r)\"><rect width=\"%d\" height=\"20\" fill=\"%s\"/><rect x=\"%d\" width=\"%d\" height=\"20\" fill=\"%s\"/><rect width=\"%d\" height=\"20\" fill=\"url(#s)\"/></g>\n",
+		labelWidth, badgeLabelColor, labelWidth, messageWidth, b.Color, width)
+	out.WriteString("<g fill=\"#fff\" text-anchor=\"middle\" font-family=\"Verdana,Geneva,DejaVu Sans,sans-serif\" font-size=\"11\">\n")
+	for _, text := range []struct {
+		x    int
+		text string
+	}{
+		{labelWidth / 2, label},
+		{labelWidth + messageWidth/2, message},
+	} {
+		// The shadow under the text
+		fmt.Fprintf(&out, "<text x=\"%d\" y=\"15\" fill=\"#010101\" fill-opacity=\".3\">%s</text><text x=\"%d\" y=\"14\">%s</text>\n", text.x, text.text, text.x, text.text)
+	}
+	out.WriteString("</g>\n</svg>\n")
+
+	return out.Bytes()
+}
+
+// badge <username> prints an SVG badge of the recent events or the streak of
+// a user, to embed in a README
+func runBadge(args []string) {
+	flags := newFlagSet("badge")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	applyProvider := addProviderFlags(flags)
+	kind := flags.String("kind", "events", "what the badge shows: events for the count of recent events or streak for the days in a row with activity")
+	label := flags.String("label", "activity", "text of the left half of the badge")
+	since := flags.String("since", "", "count the events after a date or a duration ago (default 7d for events, every fetched event for streak)")
+	var types stringList
+	flags.Var(&types, "type", "only count events of this type, e.g. PushEvent, can be repeated or comma-separated")
+	pages := flags.Int("pages", github.MaxEventPages, "maximum number of pages to fetch")
+	output := flags.String("output", "", "write the badge to this file instead of stdout")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	applyStore := addStoreFlags(flags, "file")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Usage: badge [--provider name] [--token token] [--base-url url] [--kind events|streak] [--label text] [--since 7d] [--type type] [--pages n] [--output badge.svg] [--store none|file|sqlite] [--timeout 10s] [--deadline 2m] [--retries 3] <username>")
+	}
+	err := validateBadgeKind(*kind)
+	if err != nil {
+		log.Fatalf("Error parsing --kind: %v", err)
+	}
+	if *since == "" {
+		*since = badgeSince(*kind)
+	}
+	filter := EventFilter{Types: types}
+	if *since != "" {
+		filter.Since, err = parseTimeBound(*since, false)
+		if err != nil {
+			log.Fatalf("Error parsing --since: %v", err)
+		}
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	providerConfig := ProviderConfig{Pages: *pages}
+	applyProvider(settings, &providerConfig)
+	applyTimeouts(settings)
+	provider, err := newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+	applyCache(settings)
+	applyStore(settings)
+	loadCache()
+
+	badge, err := makeBadge(provider, flags.Arg(0), *kind, *label, *since, filter)
+	if err != nil {
+		exitIfInterrupted()
+		log.Fatalf("Error fetching events of %s: %v", flags.Arg(0), err)
+	}
+	saveCache()
+
+	if *output != "" {
+		err = os.WriteFile(*output, badge.SVG(), 0644)
+		if err != nil {
+			log.Fatalf("Error writing badge: %v", err)
+		}
+		return
+	}
+	_, _ = os.Stdout.Write(badge.SVG())
+}
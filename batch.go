@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// One line of the ndjson output, either an event of the user or the error
+// fetching them
+type UserEventLine struct {
+	Username string `json:"username"`
+	Event    *Event `json:"event,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func printUserLine(line UserEventLine) {
+	encoded, err := json.Marshal(line)
+	if err != nil {
+		log.Fatalf("Error encoding event: %v", err)
+	}
+	fmt.Println(string(encoded))
+}
+
+// Fetch the events of a user and print them in the given format as each page
+// arrives, the text format prints a header with the username when header is set
+func printUserEvents(provider Provider, username string, filter EventFilter, format string, header bool) (int, error) {
+	fetchProgress.Clear()
+	if header && format == "text" {
+		fmt.Printf("== %s ==\n", username)
+	}
+
+	var csvWriter *eventCSVWriter
+	if format == "csv" {
+		var err error
+		csvWriter, err = newEventCSVWriter(os.Stdout)
+		if err != nil {
+			log.Fatalf("Error writing csv: %v", err)
+		}
+	}
+
+	renderer := newEventRenderer(os.Stdout)
+	var printed []Event
+	_, err := streamEvents(provider, username, func(page []Event) {
+		page = filter.Apply(page)
+		if filter.Limit > 0 && len(printed)+len(page) > filter.Limit {
+			page = page[:filter.Limit-len(printed)]
+		}
+		printed = append(printed, page...)
+		if csvWriter != nil {
+			if err := csvWriter.Write(page); err != nil {
+				log.Fatalf("Error writing csv: %v", err)
+			}
+			return
+		}
+		for i := range page {
+			if format == "ndjson" {
+				printUserLine(UserEventLine{Username: username, Event: &page[i]})
+				continue
+			}
+			renderer.Event(page[i])
+			renderer.Separator()
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if format == "text" {
+		fetchProgress.Clear()
+		renderer.DayNotes(printed)
+	}
+
+	return len(printed), nil
+}
+
+// Process usernames read from input one per line as they arrive, a user that
+// fails gets an error line and the batch goes on. Blank lines and lines
+// starting with # are skipped.
+func runBatch(input io.Reader, provider Provider, config Config, filter EventFilter, format string) {
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, username := range expandUsername(config, line) {
+			_, err := printUserEvents(provider, username, filter, format, true)
+			fetchProgress.UserDone()
+			if err == nil {
+				continue
+			}
+			exitIfInterrupted()
+			if format == "ndjson" {
+				fetchProgress.Clear()
+				printUserLine(UserEventLine{Username: username, Error: err.Error()})
+			} else {
+				logger.Error("Fetching events failed", "user", username, "error", err)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Error reading usernames: %v", err)
+	}
+}
+
+// The events of one of several users fetched together
+type UserEvents struct {
+	Username  string
+	Events    []Event
+	Err       error
+	RateLimit RateLimit
+}
+
+// Fetch the events of every user with at most workers requests at a time,
+// the results are in the order of usernames
+func fetchUsersEvents(newUserProvider func() (Provider, error), usernames []string, workers int) []UserEvents {
+	return fetchUsersEventsAsDone(newUserProvider, usernames, workers, nil)
+}
+
+// Fetch the events of every user like fetchUsersEvents and pass the result of
+// each user to onDone as soon as it's fetched, one call at a time
+func fetchUsersEventsAsDone(newUserProvider func() (Provider, error), usernames []string, workers int, onDone func(result UserEvents)) []UserEvents {
+	results := make([]UserEvents, len(usernames))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	var doneMutex sync.Mutex
+	for worker := 0; worker < workers && worker < len(usernames); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			provider, err := newUserProvider()
+			for i := range jobs {
+				results[i].Username = usernames[i]
+				switch {
+				case err != nil:
+					results[i].Err = err
+				// Leave the users not started yet once interrupted
+				case interrupted.Err() != nil:
+					results[i].Err = interrupted.Err()
+				default:
+					results[i].Events, results[i].Err = getEvents(provider, usernames[i])
+					results[i].RateLimit = provider.RateLimitInfo()
+					fetchProgress.UserDone()
+				}
+				if onDone != nil {
+					doneMutex.Lock()
+					onDone(results[i])
+					doneMutex.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range usernames {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// The rate limit with the least requests left, the one that matters next
+func lowestRateLimit(results []UserEvents) RateLimit {
+	var lowest RateLimit
+	for _, result := range results {
+		if result.RateLimit.Limit > 0 && (lowest.Limit == 0 || result.RateLimit.Remaining < lowest.Remaining) {
+			lowest = result.RateLimit
+		}
+	}
+
+	return lowest
+}
+
+// Print the events of several users in the given format, grouped under a
+// header per user or merged, in the order of --sort and grouped by --group-by.
+// A user that failed gets an error line in ndjson and a message on stderr
+// otherwise, the returned value tells whether any did.
+func printUsersEvents(results []UserEvents, filter EventFilter, format string, merge bool, pretty bool, order eventOrder) bool {
+	failed := false
+	var lines []UserEventLine
+	groups := make(map[string][]UserEventLine)
+	for _, result := range results {
+		if result.Err != nil {
+			failed = true
+			if format == "ndjson" {
+				printUserLine(UserEventLine{Username: result.Username, Error: result.Err.Error()})
+			} else {
+				logger.Error("Fetching events failed", "user", result.Username, "error", result.Err)
+			}
+			continue
+		}
+
+		events := filter.ApplyLimit(result.Events)
+		order.sort(events)
+		var group []UserEventLine
+		for i := range events {
+			group = append(group, UserEventLine{Username: result.Username, Event: &events[i]})
+		}
+		groups[result.Username] = group
+		lines = append(lines, group...)
+	}
+	if merge {
+		sort.SliceStable(lines, func(i, j int) bool {
+			if order.ascending {
+				return lines[i].Event.CreatedAt.Before(lines[j].Event.CreatedAt)
+			}
+			return lines[i].Event.CreatedAt.After(lines[j].Event.CreatedAt)
+		})
+	}
+
+	switch format {
+	case "json":
+		events := make([]Event, 0, len(lines))
+		for _, line := range lines {
+			events = append(events, *line.Event)
+		}
+		var output interface{} = events
+		if order.groupBy != "" {
+			output = order.group(events)
+		}
+		err := writeJSON(os.Stdout, output, pretty)
+		if err != nil {
+			log.Fatalf("Error encoding events: %v", err)
+		}
+	case "ndjson":
+		for _, line := range lines {
+			printUserLine(line)
+		}
+	case "csv":
+		events := make([]Event, 0, len(lines))
+		for _, line := range lines {
+			events = append(events, *line.Event)
+		}
+		csvWriter, err := newEventCSVWriter(os.Stdout)
+		if err == nil {
+			err = csvWriter.Write(events)
+		}
+		if err != nil {
+			log.Fatalf("Error writing csv: %v", err)
+		}
+	case "markdown":
+		var names []string
+		events := make([]Event, 0, len(lines))
+		for _, result := range results {
+			names = append(names, result.Username)
+		}
+		for _, line := range lines {
+			events = append(events, *line.Event)
+		}
+		writeMarkdownReport(os.Stdout, names, events)
+	case "html":
+		var names []string
+		events := make([]Event, 0, len(lines))
+		for _, result := range results {
+			names = append(names, result.Username)
+		}
+		for _, line := range lines {
+			events = append(events, *line.Event)
+		}
+		err := writeHTMLReport(os.Stdout, names, events)
+		if err != nil {
+			log.Fatalf("Error writing html: %v", err)
+		}
+	case "ics":
+		var names []string
+		events := make([]Event, 0, len(lines))
+		for _, result := range results {
+			names = append(names, result.Username)
+		}
+		for _, line := range lines {
+			events = append(events, *line.Event)
+		}
+		err := writeICalendar(os.Stdout, names, events)
+		if err != nil {
+			log.Fatalf("Error writing ics: %v", err)
+		}
+	case "text":
+		if merge {
+			printEventLines(lines, order)
+			break
+		}
+		for _, result := range results {
+			if result.Err == nil {
+				fmt.Printf("== %s ==\n", result.Username)
+				printEventLines(groups[result.Username], order)
+			}
+		}
+	}
+
+	return failed
+}
+
+// Print the ndjson lines of one user, its events in the order of --sort or
+// the error fetching them
+func printUserResultLines(result UserEvents, filter EventFilter, order eventOrder) {
+	if result.Err != nil {
+		printUserLine(UserEventLine{Username: result.Username, Error: result.Err.Error()})
+		return
+	}
+
+	events := filter.ApplyLimit(result.Events)
+	order.sort(events)
+	for i := range events {
+		printUserLine(UserEventLine{Username: result.Username, Event: &events[i]})
+	}
+}
+
+func printEventLines(lines []UserEventLine, order eventOrder) {
+	renderer := newEventRenderer(os.Stdout)
+	printed := make([]Event, 0, len(lines))
+	for _, line := range lines {
+		printed = append(printed, *line.Event)
+	}
+	order.print(os.Stdout, renderer, printed, func(event Event) {
+		renderer.Event(event)
+		renderer.Separator()
+	})
+	renderer.DayNotes(printed)
+}
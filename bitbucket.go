@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+const defaultBitbucketBaseURL = "https://api.bitbucket.org"
+
+type BitbucketPullRequest struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	UpdatedOn time.Time `json:"updated_on"`
+	Author    struct {
+		Nickname string `json:"nickname"`
+	} `json:"author"`
+	Destination struct {
+		Repository struct {
+			FullName string `json:"full_name"`
+			Links    struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+		} `json:"repository"`
+	} `json:"destination"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type BitbucketPullRequestPage struct {
+	Values []json.RawMessage `json:"values"`
+	Next   string            `json:"next"`
+}
+
+// Bitbucket Cloud has no user activity feed, so the pull requests a user
+// authored are used as their events
+type bitbucketProvider struct {
+	token     string
+	baseURL   string
+	maxPages  int
+	limit     int
+	rateLimit RateLimit
+	// ID of the newest stored event, paging stops at it
+	stopAt string
+}
+
+// Default number of pull request pages followed for one user
+const bitbucketMaxPages = 3
+
+func init() {
+	RegisterProvider("bitbucket", func(config ProviderConfig) (Provider, error) {
+		if config.Feed != FeedUser {
+			return nil, fmt.Errorf("bitbucket has no %s events, only the pull requests of users", config.Feed)
+		}
+
+		provider := newBitbucketProvider(config.Token, config.BaseURL)
+		if config.Pages > 0 {
+			provider.maxPages = config.Pages
+		} else if config.Limit > 0 {
+			// A limit alone fetches as many pages as it takes
+			provider.maxPages = github.MaxEventPages
+		}
+		provider.limit = config.Limit
+		provider.stopAt = config.StopAt
+		return provider, nil
+	})
+}
+
+func newBitbucketProvider(token string, baseURL string) *bitbucketProvider {
+	if baseURL == "" {
+		baseURL = defaultBitbucketBaseURL
+	}
+	if token == "" {
+		token = defaultProviderToken("bitbucket", baseURL)
+	}
+
+	return &bitbucketProvider{
+		token:    token,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		maxPages: bitbucketMaxPages,
+	}
+}
+
+func (p *bitbucketProvider) Name() string {
+	return "bitbucket"
+}
+
+func (p *bitbucketProvider) FetchEvents(username string) ([]json.RawMessage, error) {
+	var pullRequests []json.RawMessage
+	err := p.FetchEventPages(username, func(page []json.RawMessage) {
+		pullRequests = append(pullRequests, page...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pullRequests, nil
+}
+
+// Follow the next links up to maxPages, stopping early once limit pull
+// requests were fetched or at the stored one
+func (p *bitbucketProvider) FetchEventPages(username string, onPage func([]json.RawMessage)) error {
+	params := url.Values{}
+	params.Set("sort", "-updated_on")
+	params.Set("pagelen", "50")
+	for _, state := range []string{"OPEN", "MERGED", "DECLINED"} {
+		params.Add("state", state)
+	}
+
+	bitbucketUrl := fmt.Sprintf("%s/2.0/pullrequests/%s?%s", p.baseURL, url.PathEscape(username), params.Encode())
+	fetched := 0
+	for page := 0; page < p.maxPages && bitbucketUrl != ""; page++ {
+		req, err := http.NewRequestWithContext(interrupted, http.MethodGet, bitbucketUrl, nil)
+		if err != nil {
+			return err
+		}
+		if p.token != "" {
+			req.Header.Set("Authorization", "Bearer "+p.token)
+		}
+
+		var pullRequestPage BitbucketPullRequestPage
+		header, err := doJSONRequest(req, &pullRequestPage)
+		p.rateLimit = github.ParseRateLimit(header, "X-RateLimit-")
+		if err != nil {
+			return err
+		}
+
+		pullRequests := pullRequestPage.Values
+		if p.limit > 0 && fetched+len(pullRequests) > p.limit {
+			pullRequests = pullRequests[:p.limit-fetched]
+		}
+		pullRequests, known := p.pullRequestsBefore(pullRequests)
+		fetched += len(pullRequests)
+		onPage(pullRequests)
+
+		if known || p.limit > 0 && fetched >= p.limit {
+			break
+		}
+		bitbucketUrl = pullRequestPage.Next
+	}
+
+	return nil
+}
+
+// The pull requests before the stored one, eventsBefore can't find it since
+// the event ID isn't the pull request ID alone
+func (p *bitbucketProvider) pullRequestsBefore(pullRequests []json.RawMessage) ([]json.RawMessage, bool) {
+	if p.stopAt == "" {
+		return pullRequests, false
+	}
+	for i, raw := range pullRequests {
+		var pullRequest BitbucketPullRequest
+		if json.Unmarshal(raw, &pullRequest) == nil && bitbucketEventID(pullRequest) == p.stopAt {
+			return pullRequests[:i], true
+		}
+	}
+
+	return pullRequests, false
+}
+
+// A pull request is listed once with its last update, so the update time is
+// part of the ID: an updated pull request is a new event, not a duplicate
+func bitbucketEventID(pullRequest BitbucketPullRequest) string {
+	return fmt.Sprintf("%s#%d@%s", pullRequest.Destination.Repository.FullName, pullRequest.ID, pullRequest.UpdatedOn.UTC().Format(time.RFC3339))
+}
+
+// The web pages of the instance, bitbucket.org for api.bitbucket.org
+func bitbucketWebURL(baseURL string) string {
+	return strings.Replace(baseURL, "://api.", "://", 1)
+}
+
+func (p *bitbucketProvider) Normalize(raw json.RawMessage) (Event, error) {
+	var pullRequest BitbucketPullRequest
+	err := json.Unmarshal(raw, &pullRequest)
+	if err != nil {
+		return Event{}, err
+	}
+
+	repoName := pullRequest.Destination.Repository.FullName
+	repoURL := pullRequest.Destination.Repository.Links.HTML.Href
+	if repoURL == "" {
+		repoURL = bitbucketWebURL(p.baseURL) + "/" + repoName
+	}
+	event := Event{
+		ID:       bitbucketEventID(pullRequest),
+		Provider: p.Name(),
+		Type:     "PullRequestEvent",
+		Action:   bitbucketAction(pullRequest.State),
+		Target: EventTarget{
+			Kind:   TargetPullRequest,
+			Number: pullRequest.ID,
+			Title:  pullRequest.Title,
+			URL:    pullRequest.Links.HTML.Href,
+		},
+		Actor:     EventActor{Login: pullRequest.Author.Nickname},
+		Repo:      EventRepo{Name: repoName, URL: repoURL},
+		CreatedAt: pullRequest.UpdatedOn,
+		Raw:       raw,
+	}
+
+	return event, nil
+}
+
+// Map the state of a pull request to the last action taken on it
+func bitbucketAction(state string) string {
+	switch state {
+	case "OPEN":
+		return ActionOpened
+	case "MERGED":
+		return ActionMerged
+	case "DECLINED", "SUPERSEDED":
+		return ActionClosed
+	default:
+		return ActionOther
+	}
+}
+
+func (p *bitbucketProvider) RateLimitInfo() RateLimit {
+	return p.rateLimit
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// A bookmark keeps the whole event so it outlives the cache
+type Bookmark struct {
+	Event        Event     `json:"event"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+// Bookmark the event or remove its bookmark, returns whether the event is
+// bookmarked afterwards
+func toggleBookmark(event Event) bool {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	if _, ok := archive.Bookmarks[event.ID]; ok {
+		delete(archive.Bookmarks, event.ID)
+		return false
+	}
+
+	archive.Bookmarks[event.ID] = Bookmark{Event: event, BookmarkedAt: time.Now()}
+	return true
+}
+
+func isBookmarked(id string) bool {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	_, ok := archive.Bookmarks[id]
+	return ok
+}
+
+// Bookmarks ordered from the most recently bookmarked
+func listBookmarks() []Bookmark {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	bookmarks := make([]Bookmark, 0, len(archive.Bookmarks))
+	for _, bookmark := range archive.Bookmarks {
+		bookmarks = append(bookmarks, bookmark)
+	}
+	sort.Slice(bookmarks, func(i, j int) bool {
+		return bookmarks[i].BookmarkedAt.After(bookmarks[j].BookmarkedAt)
+	})
+
+	return bookmarks
+}
+
+func runBookmark(args []string) {
+	flags := newFlagSet("bookmark")
+	_ = flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) < 1 {
+		log.Fatalf("Usage: bookmark <event-id>")
+	}
+
+	err := loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	id := args[0]
+	event := Event{ID: id}
+	if !isBookmarked(id) {
+		loadCache()
+		var found bool
+		event, found = findCachedEvent(id)
+		if !found {
+			log.Fatalf("Event %s is not in the cache, fetch the events that contain it first", id)
+		}
+	}
+
+	if toggleBookmark(event) {
+		fmt.Printf("Bookmarked event %s\n", id)
+	} else {
+		fmt.Printf("Removed bookmark of event %s\n", id)
+	}
+
+	err = saveArchive()
+	if err != nil {
+		log.Fatalf("Error saving archive: %v", err)
+	}
+}
+
+func runBookmarks(args []string) {
+	flags := newFlagSet("bookmarks")
+	format := flags.String("format", "text", "output format: text or json")
+	applyTimeDisplay := addTimeDisplayFlags(flags)
+	_ = flags.Parse(args)
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	applyTimeDisplay(newSettingsResolver(flags, config))
+
+	err = loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	bookmarks := listBookmarks()
+	switch *format {
+	case "json":
+		err = printJSON(bookmarks)
+		if err != nil {
+			log.Fatalf("Error encoding bookmarks: %v", err)
+		}
+	case "text":
+		renderer := newEventRenderer(os.Stdout)
+		for _, bookmark := range bookmarks {
+			renderer.Event(bookmark.Event)
+			renderer.Field("Bookmarked At", renderer.Timestamp(bookmark.BookmarkedAt))
+			renderer.Separator()
+		}
+	default:
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+}
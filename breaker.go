@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Consecutive failures that open the breaker of a target
+const breakerThreshold = 3
+
+// Longest wait between two attempts while the breaker is open
+const breakerMaxBackoff = 30 * time.Minute
+
+// A circuit breaker for one polled target. After breakerThreshold failures
+// in a row it opens and requests are skipped until the backoff elapsed, the
+// backoff doubles on every failure while open. A success closes it again.
+type circuitBreaker struct {
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{}
+}
+
+// Whether a request may be sent now
+func (b *circuitBreaker) Allow() bool {
+	return !time.Now().Before(b.openUntil)
+}
+
+func (b *circuitBreaker) Open() bool {
+	return b.failures >= breakerThreshold
+}
+
+// Record a successful request, returns true if the breaker was open
+func (b *circuitBreaker) Success() bool {
+	wasOpen := b.Open()
+	b.failures = 0
+	b.backoff = 0
+	b.openUntil = time.Time{}
+
+	return wasOpen
+}
+
+// Record a failed request, interval is the normal polling interval the
+// backoff starts from. Returns true when the breaker opens or stays open.
+func (b *circuitBreaker) Failure(interval time.Duration) bool {
+	b.failures++
+	if !b.Open() {
+		return false
+	}
+
+	if b.backoff == 0 {
+		b.backoff = interval
+	} else {
+		b.backoff *= 2
+	}
+	if b.backoff > breakerMaxBackoff {
+		b.backoff = breakerMaxBackoff
+	}
+	b.openUntil = time.Now().Add(b.backoff)
+
+	return true
+}
+
+// Breaker state for logs and status lines
+func (b *circuitBreaker) String() string {
+	if !b.Open() {
+		return "closed"
+	}
+
+	return fmt.Sprintf("open after %d failures, retry in %s", b.failures, time.Until(b.openUntil).Round(time.Second))
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// Open a URL with the default browser of the platform
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	return cmd.Run()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// A cache keeps the fetched events of every feed under their cache key. It
+// is loaded before the commands read it and saved after they fetch, the
+// backends that write at once don't need either.
+type Cache interface {
+	Load() error
+	Save() error
+	// Get returns the item of a key, expired or not, and marks it used
+	Get(key string) (github.CacheItem, bool)
+	// Peek returns the item like Get without marking it used
+	Peek(key string) (github.CacheItem, bool)
+	Set(key string, item github.CacheItem)
+	Delete(key string)
+	// Keys returns the keys of every item, sorted
+	Keys() []string
+	// Where the items are kept, shown by cache path and cache stats
+	Location() string
+}
+
+// Fetched events of every user, set with cache.backend or --cache-backend
+var cache Cache = newFileCache(defaultCachePath())
+
+// The most entries the file and memory caches keep, set with
+// cache.max_entries or --cache-max-entries
+var cacheMaxEntries = 500
+
+// How long expired entries are kept to revalidate them with their ETag, set
+// with retention.cache
+var cacheRetention = 7 * 24 * time.Hour
+
+// Cache backends by the name --cache-backend takes, location is the file of
+// the file backend and the URL of the Redis one
+var cacheBackends = map[string]func(location string) Cache{
+	"file":   newFileCache,
+	"memory": newMemoryCache,
+	"redis":  newRedisCache,
+}
+
+func cacheBackendNames() []string {
+	names := make([]string, 0, len(cacheBackends))
+	for name := range cacheBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func defaultCachePath() string {
+	return filepath.Join(cacheDir(), "cache.json")
+}
+
+// Apply the entry cap and the retention to the caches that keep the items
+// themselves, Redis expires them on its own
+func limitCache(c Cache) {
+	var items *github.Cache
+	switch c := c.(type) {
+	case fileCache:
+		items = c.Cache
+	case memoryCache:
+		items = c.Cache
+	default:
+		return
+	}
+	items.MaxEntries = cacheMaxEntries
+	items.Retention = cacheRetention
+}
+
+// The items are kept in memory and saved to a JSON file
+type fileCache struct {
+	*github.Cache
+}
+
+func newFileCache(path string) Cache {
+	return fileCache{github.NewCache(path)}
+}
+
+func (c fileCache) Location() string {
+	return c.Path
+}
+
+// The items only last as long as the process, for serve and daemon runs that
+// shouldn't write to the disk
+type memoryCache struct {
+	*github.Cache
+}
+
+func newMemoryCache(string) Cache {
+	return memoryCache{github.NewCache("")}
+}
+
+func (c memoryCache) Load() error {
+	return nil
+}
+
+func (c memoryCache) Save() error {
+	return nil
+}
+
+func (c memoryCache) Location() string {
+	return "memory"
+}
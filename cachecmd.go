@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Remove the cached events of every user, or of one user on any provider,
+// only the expired ones when expiredOnly is set. Returns the number of
+// removed entries.
+func clearCache(username string, expiredOnly bool) int {
+	removed := 0
+	for _, key := range cache.Keys() {
+		if username != "" && !strings.HasSuffix(key, "-events-"+username) {
+			continue
+		}
+		if item, _ := cache.Peek(key); expiredOnly && !item.Expired() {
+			continue
+		}
+		cache.Delete(key)
+		removed++
+	}
+
+	return removed
+}
+
+type CacheEntry struct {
+	Key       string    `json:"key"`
+	Events    int       `json:"events"`
+	Bytes     int       `json:"bytes"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+	ETag      bool      `json:"etag"`
+}
+
+type CacheStats struct {
+	Path    string `json:"path"`
+	Bytes   int64  `json:"bytes"`
+	Entries int    `json:"entries"`
+	Expired int    `json:"expired"`
+	Events  int    `json:"events"`
+	ETags   int    `json:"etags"`
+}
+
+func cacheEntries() []CacheEntry {
+	var entries []CacheEntry
+	for _, key := range cache.Keys() {
+		item, _ := cache.Peek(key)
+		entries = append(entries, CacheEntry{
+			Key:       key,
+			Events:    len(cachedEvents(item)),
+			Bytes:     len(item.Data),
+			ExpiresAt: item.ExpiresAt,
+			Expired:   item.Expired(),
+			ETag:      item.ETag != "",
+		})
+	}
+
+	return entries
+}
+
+func cacheStats() CacheStats {
+	stats := CacheStats{Path: cache.Location()}
+	_, isFile := cache.(fileCache)
+	if info, err := os.Stat(cache.Location()); isFile && err == nil {
+		stats.Bytes = info.Size()
+	}
+	for _, entry := range cacheEntries() {
+		// Without a file the size is the one of the cached events
+		if !isFile {
+			stats.Bytes += int64(entry.Bytes)
+		}
+		stats.Entries++
+		stats.Events += entry.Events
+		if entry.Expired {
+			stats.Expired++
+		}
+		if entry.ETag {
+			stats.ETags++
+		}
+	}
+
+	return stats
+}
+
+// How long until a time, or how long ago it was
+func formatExpiry(expiresAt time.Time) string {
+	left := time.Until(expiresAt).Round(time.Second)
+	if left <= 0 {
+		return fmt.Sprintf("expired %s ago", -left)
+	}
+
+	return fmt.Sprintf("in %s", left)
+}
+
+// cache list, cache stats, cache clear [--expired] [username], cache path
+func runCache(args []string) {
+	flags := newFlagSet("cache")
+	applyCache := addCacheFlags(flags)
+	expired := flags.Bool("expired", false, "clear only the expired entries")
+	format := flags.String("format", "text", "output format of list and stats: text or json")
+	_ = flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) < 1 {
+		log.Fatalf("Usage: cache list | stats | clear [--expired] [username] | path")
+	}
+	// Flags can also follow the action
+	action := args[0]
+	_ = flags.Parse(args[1:])
+	args = flags.Args()
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	applyCache(newSettingsResolver(flags, config))
+	if *format != "text" && *format != "json" {
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+
+	switch action {
+	case "list":
+		loadCache()
+		entries := cacheEntries()
+		if *format == "json" {
+			if entries == nil {
+				entries = []CacheEntry{}
+			}
+			err = printJSON(entries)
+			if err != nil {
+				log.Fatalf("Error encoding cache entries: %v", err)
+			}
+			return
+		}
+		for _, entry := range entries {
+			fmt.Printf("Key: %s\n", entry.Key)
+			fmt.Printf("Events: %d\n", entry.Events)
+			fmt.Printf("Size: %d bytes\n", entry.Bytes)
+			fmt.Printf("Expires: %s (%s)\n", entry.ExpiresAt.Local().Format("2006-01-02 15:04:05"), formatExpiry(entry.ExpiresAt))
+			fmt.Printf("ETag: %v\n", entry.ETag)
+			fmt.Println("----------------------")
+		}
+		if len(entries) == 0 {
+			fmt.Println("The cache is empty")
+		}
+	case "stats":
+		loadCache()
+		stats := cacheStats()
+		if *format == "json" {
+			err = printJSON(stats)
+			if err != nil {
+				log.Fatalf("Error encoding cache stats: %v", err)
+			}
+			return
+		}
+		fmt.Printf("Path: %s\n", stats.Path)
+		fmt.Printf("Size: %d bytes\n", stats.Bytes)
+		fmt.Printf("Entries: %d (%d expired, %d with an ETag)\n", stats.Entries, stats.Expired, stats.ETags)
+		fmt.Printf("Events: %d\n", stats.Events)
+	case "clear":
+		username := ""
+		if len(args) > 0 {
+			username = args[0]
+		}
+
+		loadCache()
+		removed := clearCache(username, *expired)
+		saveCache()
+		fmt.Printf("Removed %d cache entries\n", removed)
+	case "path":
+		path := cache.Location()
+		if _, isFile := cache.(fileCache); isFile {
+			if absolute, err := filepath.Abs(path); err == nil {
+				path = absolute
+			}
+		}
+		fmt.Println(path)
+	default:
+		log.Fatalf("Unknown cache action %q, expected list, stats, clear or path", action)
+	}
+}
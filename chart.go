@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The greens of the Github calendar, from no activity to the busiest day
+var chartColors = []color.RGBA{
+	{0xeb, 0xed, 0xf0, 0xff},
+	{0x9b, 0xe9, 0xa8, 0xff},
+	{0x40, 0xc4, 0x63, 0xff},
+	{0x30, 0xa1, 0x4e, 0xff},
+	{0x21, 0x6e, 0x39, 0xff},
+}
+
+const (
+	// Side of a heatmap day and width of a bar, in pixels
+	chartCell   = 10
+	chartGap    = 3
+	chartMargin = 4
+	// Height of the bar of the busiest day
+	chartBarHeight = 100
+)
+
+// A filled rectangle of a chart, the day it stands for is its tooltip in SVG
+type chartRect struct {
+	X, Y, Width, Height int
+	Color               color.RGBA
+	Day                 ContributionDay
+}
+
+// A chart of the days laid out in pixels, encoded as SVG or PNG
+type chart struct {
+	Width, Height int
+	Rects         []chartRect
+}
+
+// One square per day, one row per weekday and one column per week starting
+// on Sunday like renderHeatmap
+func heatmapChart(days []ContributionDay) chart {
+	busiest := busiestDay(days)
+	pitch := chartCell + chartGap
+
+	c := chart{Height: 2*chartMargin + 7*pitch - chartGap}
+	var firstSunday time.Time
+	for _, day := range days {
+		date, err := time.Parse(dayLayout, day.Date)
+		if err != nil {
+			continue
+		}
+		if firstSunday.IsZero() {
+			firstSunday = date.AddDate(0, 0, -int(date.Weekday()))
+		}
+		week := int(date.Sub(firstSunday).Hours()/24) / 7
+
+		c.Rects = append(c.Rects, chartRect{
+			X:      chartMargin + week*pitch,
+			Y:      chartMargin + int(date.Weekday())*pitch,
+			Width:  chartCell,
+			Height: chartCell,
+			Color:  chartColors[heatmapLevel(day.Count, busiest)],
+			Day:    day,
+		})
+		c.Width = 2*chartMargin + (week+1)*pitch - chartGap
+	}
+
+	return c
+}
+
+// One bar per day scaled to the busiest day, the days without activity are
+// a thin grey line
+func barChart(days []ContributionDay) chart {
+	busiest := busiestDay(days)
+	pitch := chartCell + chartGap
+
+	c := chart{
+		Width:  2*chartMargin + len(days)*pitch - chartGap,
+		Height: 2*chartMargin + chartBarHeight,
+	}
+	for i, day := range days {
+		height := 2
+		if day.Count > 0 {
+			height = day.Count * chartBarHeight / busiest
+			if height < 2 {
+				height = 2
+			}
+		}
+
+		c.Rects = append(c.Rects, chartRect{
+			X:      chartMargin + i*pitch,
+			Y:      chartMargin + chartBarHeight - height,
+			Width:  chartCell,
+			Height: height,
+			Color:  chartColors[heatmapLevel(day.Count, busiest)],
+			Day:    day,
+		})
+	}
+
+	return c
+}
+
+func busiestDay(days []ContributionDay) int {
+	busiest := 0
+	for _, day := range days {
+		if day.Count > busiest {
+			busiest = day.Count
+		}
+	}
+
+	return busiest
+}
+
+// SVG with a tooltip per day, unit names what the days count, e.g. event
+func (c chart) svg(title string, unit string) []byte {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", c.Width, c.Height, c.Width, c.Height)
+	fmt.Fprintf(&out, "<title>%s</title>\n", html.EscapeString(title))
+	for _, rect := range c.Rects {
+		fmt.Fprintf(&out, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"2\" fill=\"#%02x%02x%02x\"><title>%s: %d %s</title></rect>\n",
+			rect.X, rect.Y, rect.Width, rect.Height, rect.Color.R, rect.Color.G, rect.Color.B,
+			rect.Day.Date, rect.Day.Count, plural(rect.Day.Count, unit))
+	}
+	out.WriteString("</svg>\n")
+
+	return out.Bytes()
+}
+
+// PNG on a transparent background, without the tooltips
+func (c chart) png() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	for _, rect := range c.Rects {
+		bounds := image.Rect(rect.X, rect.Y, rect.X+rect.Width, rect.Y+rect.Height)
+		draw.Draw(img, bounds, image.NewUniform(rect.Color), image.Point{}, draw.Src)
+	}
+
+	var out bytes.Buffer
+	err := png.Encode(&out, img)
+	return out.Bytes(), err
+}
+
+// Check the file --output writes and the chart --chart draws
+func validateChartOutput(path string, kind string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg", ".png":
+	default:
+		return fmt.Errorf("unknown chart file %q, expected a .svg or .png file", path)
+	}
+	if kind != "heatmap" && kind != "bars" {
+		return fmt.Errorf("unknown chart %q, expected heatmap or bars", kind)
+	}
+
+	return nil
+}
+
+// Write the heatmap or bar chart of the days to path, as SVG or PNG by its
+// extension
+func writeChart(path string, kind string, title string, unit string, days []ContributionDay) error {
+	c := heatmapChart(days)
+	if kind == "bars" {
+		c = barChart(days)
+	}
+
+	data := c.svg(title, unit)
+	if strings.ToLower(filepath.Ext(path)) == ".png" {
+		var err error
+		data, err = c.png()
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// The summaries of two users side by side, with what they have in common
+type ActivityComparison struct {
+	Users [2]ActivitySummary `json:"users"`
+	// Repositories both users have events in
+	SharedRepos []string `json:"shared_repos"`
+	// Events per local day as YYYY-MM-DD, for each user
+	PerDay     map[string][2]int `json:"per_day"`
+	DaysActive [2]int            `json:"days_active"`
+	// Days both users have events on
+	SharedDays []string `json:"shared_days"`
+}
+
+func compareEvents(usernames [2]string, events [2][]Event) ActivityComparison {
+	comparison := ActivityComparison{
+		SharedRepos: []string{},
+		PerDay:      make(map[string][2]int),
+		SharedDays:  []string{},
+	}
+	for i := range usernames {
+		comparison.Users[i] = summarizeEvents(usernames[i], events[i])
+		for _, event := range events[i] {
+			day := event.CreatedAt.Local().Format("2006-01-02")
+			counts := comparison.PerDay[day]
+			if counts[i] == 0 {
+				comparison.DaysActive[i]++
+			}
+			counts[i]++
+			comparison.PerDay[day] = counts
+		}
+	}
+
+	for repo := range comparison.Users[0].PerRepo {
+		if comparison.Users[1].PerRepo[repo] > 0 {
+			comparison.SharedRepos = append(comparison.SharedRepos, repo)
+		}
+	}
+	sort.Strings(comparison.SharedRepos)
+	for day, counts := range comparison.PerDay {
+		if counts[0] > 0 && counts[1] > 0 {
+			comparison.SharedDays = append(comparison.SharedDays, day)
+		}
+	}
+	sort.Strings(comparison.SharedDays)
+
+	return comparison
+}
+
+func printComparison(comparison ActivityComparison) {
+	first, second := comparison.Users[0], comparison.Users[1]
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(table, "\t%s\t%s\n", first.Username, second.Username)
+	fmt.Fprintf(table, "Total Events\t%d\t%d\n", first.Total, second.Total)
+	fmt.Fprintf(table, "Commits Pushed\t%d\t%d\n", first.Commits, second.Commits)
+	fmt.Fprintf(table, "Repositories\t%d\t%d\n", len(first.PerRepo), len(second.PerRepo))
+	fmt.Fprintf(table, "Days Active\t%d\t%d\n", comparison.DaysActive[0], comparison.DaysActive[1])
+	fmt.Fprintf(table, "Busiest Day\t%s\t%s\n", first.BusiestDay, second.BusiestDay)
+
+	// Every type either user has, the most frequent overall first
+	types := make([]string, 0, len(first.PerType)+len(second.PerType))
+	for eventType := range first.PerType {
+		types = append(types, eventType)
+	}
+	for eventType := range second.PerType {
+		if first.PerType[eventType] == 0 {
+			types = append(types, eventType)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool {
+		a := first.PerType[types[i]] + second.PerType[types[i]]
+		b := first.PerType[types[j]] + second.PerType[types[j]]
+		if a != b {
+			return a > b
+		}
+		return types[i] < types[j]
+	})
+	fmt.Fprintf(table, "\nTYPE\t%s\t%s\n", first.Username, second.Username)
+	for _, eventType := range types {
+		fmt.Fprintf(table, "%s\t%d\t%d\n", eventType, first.PerType[eventType], second.PerType[eventType])
+	}
+
+	days := make([]string, 0, len(comparison.PerDay))
+	for day := range comparison.PerDay {
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	fmt.Fprintf(table, "\nDAY\t%s\t%s\n", first.Username, second.Username)
+	for _, day := range days {
+		counts := comparison.PerDay[day]
+		fmt.Fprintf(table, "%s\t%d\t%d\n", day, counts[0], counts[1])
+	}
+	table.Flush()
+
+	fmt.Println()
+	if len(comparison.SharedRepos) > 0 {
+		fmt.Printf("Shared Repositories: %s\n", strings.Join(comparison.SharedRepos, ", "))
+	} else {
+		fmt.Println("Shared Repositories: none")
+	}
+	fmt.Printf("Days Both Active: %d of %d\n", len(comparison.SharedDays), len(comparison.PerDay))
+}
+
+// compare <user1> <user2> fetches both users at the same time and prints their
+// activity side by side
+func runCompare(args []string) {
+	flags := newFlagSet("compare")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	applyProvider := addProviderFlags(flags)
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	var types stringList
+	flags.Var(&types, "type", "only compare events of this type, e.g. PushEvent, can be repeated or comma-separated (replaces the preset types)")
+	applyDateRange := addDateRangeFlags(flags)
+	applyFilters := addFilterFlags(flags)
+	format := flags.String("format", "text", "output format: text or json")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		log.Fatalf("Usage: compare [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 7d] [--until 2024-01-31] [--format text|json] [--timeout 10s] [--deadline 2m] [--retries 3] <user1> <user2>")
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	var providerConfig ProviderConfig
+	applyProvider(settings, &providerConfig)
+	applyTimeouts(settings)
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+	if len(types) > 0 {
+		filter.Types = types
+	}
+	applyDateRange(&filter)
+	applyFilters(&filter)
+	_, err = newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+
+	applyCache(settings)
+	loadCache()
+	cancelOnInterrupt()
+
+	// An alias has to name a single user to be compared
+	var usernames [2]string
+	for i, name := range flags.Args() {
+		expanded := expandUsername(config, name)
+		if len(expanded) != 1 {
+			log.Fatalf("compare takes two users, %s is a group of %d", name, len(expanded))
+		}
+		usernames[i] = expanded[0]
+	}
+	results := fetchUsersEvents(func() (Provider, error) {
+		return newProvider(*providerName, providerConfig)
+	}, usernames[:], 2)
+	var events [2][]Event
+	for i, result := range results {
+		if result.Err != nil {
+			exitIfInterrupted()
+			log.Fatalf("Error fetching events of %s: %v", result.Username, result.Err)
+		}
+		events[i] = filter.Apply(result.Events)
+	}
+	saveCache()
+
+	comparison := compareEvents(usernames, events)
+	if *format == "json" {
+		err = printJSON(comparison)
+		if err != nil {
+			log.Fatalf("Error encoding comparison: %v", err)
+		}
+		return
+	}
+	printComparison(comparison)
+}
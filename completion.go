@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+type GithubSearchUsersResponse struct {
+	Items []struct {
+		Login string `json:"login"`
+	} `json:"items"`
+}
+
+// Usernames with Github events in the cache, most of the time the ones the
+// user queried recently
+func cachedUsernames() []string {
+	var usernames []string
+	for _, key := range cache.Keys() {
+		if strings.HasPrefix(key, "github-events-") {
+			usernames = append(usernames, strings.TrimPrefix(key, "github-events-"))
+		}
+	}
+
+	return usernames
+}
+
+func searchUsernames(prefix string) ([]string, error) {
+	params := url.Values{}
+	params.Set("q", prefix+" in:login")
+	params.Set("per_page", "10")
+
+	var searchResponse GithubSearchUsersResponse
+	err := getGithubJSON("search/users?"+params.Encode(), &searchResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make([]string, 0, len(searchResponse.Items))
+	for _, item := range searchResponse.Items {
+		usernames = append(usernames, item.Login)
+	}
+
+	return usernames, nil
+}
+
+// Logins starting with prefix, cached names first then the Github user
+// search. Search errors are ignored so completion never gets in the way.
+func completeUsernames(prefix string) []string {
+	seen := make(map[string]bool)
+	var usernames []string
+	add := func(username string) {
+		key := strings.ToLower(username)
+		if !seen[key] && strings.HasPrefix(key, strings.ToLower(prefix)) {
+			seen[key] = true
+			usernames = append(usernames, username)
+		}
+	}
+
+	for _, username := range cachedUsernames() {
+		add(username)
+	}
+	if prefix != "" {
+		found, _ := searchUsernames(prefix)
+		for _, username := range found {
+			add(username)
+		}
+	}
+
+	return usernames
+}
+
+// Number of single rune edits to turn a into b, case insensitive
+func editDistance(a string, b string) int {
+	source := []rune(strings.ToLower(a))
+	target := []rune(strings.ToLower(b))
+
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
+
+// The closest existing login to a username that was not found, searched
+// with the whole name then with its first half to catch typos at the end.
+// Returns an empty string when nothing is close enough.
+func suggestUsername(username string) string {
+	candidates := cachedUsernames()
+	for _, query := range []string{username, username[:(len(username)+1)/2]} {
+		found, err := searchUsernames(query)
+		if err != nil {
+			break
+		}
+		candidates = append(candidates, found...)
+		if len(found) > 0 {
+			break
+		}
+	}
+
+	maxDistance := len(username) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	best := ""
+	bestDistance := maxDistance + 1
+	for _, candidate := range candidates {
+		distance := editDistance(username, candidate)
+		if distance > 0 && distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	return best
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		if !commands[name].Hidden {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Event types starting with prefix for --type, which takes a comma-separated
+// list: the types before the last comma are kept
+func completeEventTypes(prefix string) []string {
+	head := ""
+	if i := strings.LastIndex(prefix, ","); i >= 0 {
+		head, prefix = prefix[:i+1], prefix[i+1:]
+	}
+
+	var types []string
+	for _, eventType := range github.EventTypes() {
+		if strings.HasPrefix(strings.ToLower(eventType), strings.ToLower(prefix)) {
+			types = append(types, head+eventType)
+		}
+	}
+
+	return types
+}
+
+// Hidden command used by the completion scripts: __complete users <prefix>
+// or __complete types <prefix>
+func runComplete(args []string) {
+	if len(args) < 1 {
+		return
+	}
+
+	prefix := ""
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+
+	// Completion runs as the shell draws the prompt
+	logger.SetOutput(io.Discard)
+	var completions []string
+	switch args[0] {
+	case "users":
+		loadCache()
+		completions = completeUsernames(prefix)
+	case "types":
+		completions = completeEventTypes(prefix)
+	}
+	for _, completion := range completions {
+		fmt.Println(completion)
+	}
+}
+
+// The completion scripts take the name of the program and the commands
+const bashCompletion = `_%[1]s_complete() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local prev="${COMP_WORDS[COMP_CWORD-1]}"
+    COMPREPLY=()
+    case "$cur" in
+        -*) return ;;
+    esac
+    case "$prev" in
+        --type|--exclude-type)
+            COMPREPLY=( $(%[1]s __complete types "$cur" 2>/dev/null) )
+            return ;;
+        --provider|--token|--base-url|--interval|--preset|--format) return ;;
+    esac
+    if [[ $COMP_CWORD -eq 1 ]]; then
+        COMPREPLY=( $(compgen -W "%[2]s" -- "$cur") )
+    fi
+    COMPREPLY+=( $(%[1]s __complete users "$cur" 2>/dev/null) )
+}
+complete -F _%[1]s_complete %[1]s
+`
+
+const zshCompletion = `#compdef %[1]s
+_%[1]s() {
+    local cur="${words[CURRENT]}" prev="${words[CURRENT-1]}"
+    case "$prev" in
+        --type|--exclude-type)
+            compadd -- ${(f)"$(%[1]s __complete types "$cur" 2>/dev/null)"}
+            return ;;
+        --provider|--token|--base-url|--interval|--preset|--format) return ;;
+    esac
+    [[ "$cur" == -* ]] && return
+    if (( CURRENT == 2 )); then
+        compadd -- %[2]s
+    fi
+    compadd -- ${(f)"$(%[1]s __complete users "$cur" 2>/dev/null)"}
+}
+compdef _%[1]s %[1]s
+`
+
+const fishCompletion = `function __%[1]s_complete
+    %[1]s __complete $argv (commandline -ct) 2>/dev/null
+end
+complete -c %[1]s -f
+complete -c %[1]s -n __fish_use_subcommand -a "%[2]s"
+complete -c %[1]s -a "(__%[1]s_complete users)"
+complete -c %[1]s -l type -x -a "(__%[1]s_complete types)"
+complete -c %[1]s -l exclude-type -x -a "(__%[1]s_complete types)"
+`
+
+const powershellCompletion = `Register-ArgumentCompleter -Native -CommandName '%[1]s' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })
+    $position = if ($wordToComplete) { $words.Count - 1 } else { $words.Count }
+    $previous = $words[$position - 1]
+    $candidates = @()
+    if ($previous -eq '--type' -or $previous -eq '--exclude-type') {
+        $candidates = & '%[1]s' __complete types $wordToComplete 2>$null
+    } elseif ($wordToComplete -notlike '-*') {
+        if ($position -eq 1) {
+            $candidates += '%[2]s' -split ' ' | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        $candidates += & '%[1]s' __complete users $wordToComplete 2>$null
+    }
+    $candidates | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
+
+var completionScripts = map[string]string{
+	"bash":       bashCompletion,
+	"zsh":        zshCompletion,
+	"fish":       fishCompletion,
+	"powershell": powershellCompletion,
+}
+
+func runCompletion(args []string) {
+	flags := newFlagSet("completion")
+	_ = flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) != 1 {
+		log.Fatalf("Usage: completion bash|zsh|fish|powershell")
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		log.Fatalf("Unknown shell %q, expected bash, zsh, fish or powershell", args[0])
+	}
+
+	program := filepath.Base(os.Args[0])
+	fmt.Printf(script, program, strings.Join(commandNames(), " "))
+}
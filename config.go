@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values of the config file keyed by "section.key", a value is a string,
+// bool, int or []string
+type Config map[string]interface{}
+
+// config.toml in the user config directory, or config.yaml next to it when
+// only that one exists
+func configPath() string {
+	path := configFilePath("toml")
+	yamlPath := configFilePath("yaml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err := os.Stat(yamlPath); err == nil {
+			return yamlPath
+		}
+	}
+
+	return path
+}
+
+// The config file with an extension in the user config directory
+func configFilePath(extension string) string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "config." + extension
+	}
+
+	return filepath.Join(configDir, "github-activity", "config."+extension)
+}
+
+func isYAMLConfig(path string) bool {
+	return strings.HasSuffix(path, ".yaml")
+}
+
+// Load the config file, a missing file is an empty config
+func loadConfig() (Config, error) {
+	path := configPath()
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Config{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	if isYAMLConfig(path) {
+		return parseYAMLConfig(bufio.NewScanner(file))
+	}
+	return parseConfig(bufio.NewScanner(file))
+}
+
+// Parse the subset of TOML the config file uses: [sections], comments and
+// key = value pairs where the value is a string, bool, integer or an array
+// of strings on a single line
+func parseConfig(scanner *bufio.Scanner) (Config, error) {
+	config := Config{}
+	section := ""
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			// Hosts may be quoted like in TOML: [gitea.hosts."git.mycorp.com"]
+			section = strings.ReplaceAll(strings.TrimSpace(line[1:len(line)-1]), `"`, "")
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("config line %d: expected key = value", lineNumber)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		if section != "" {
+			key = section + "." + key
+		}
+		value, err := parseConfigValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("config line %d: %v", lineNumber, err)
+		}
+		config[key] = value
+	}
+
+	return config, scanner.Err()
+}
+
+// Parse the subset of YAML the config file can also be written in: mappings
+// nested by indentation, comments, and scalars, [inline] arrays or "- item"
+// lists as values. The keys are the same as in TOML, the sections being the
+// parent mappings.
+func parseYAMLConfig(scanner *bufio.Scanner) (Config, error) {
+	type parent struct {
+		indent int
+		key    string
+	}
+
+	config := Config{}
+	var parents []parent
+	// The key without a value the "- item" lines belong to
+	listKey := ""
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimRight(stripComment(scanner.Text()), " \t")
+		line := strings.TrimSpace(text)
+		if line == "" || line == "---" {
+			continue
+		}
+
+		if line == "-" || strings.HasPrefix(line, "- ") {
+			if listKey == "" {
+				return nil, fmt.Errorf("config line %d: list item without a key", lineNumber)
+			}
+			items, _ := config[listKey].([]string)
+			config[listKey] = append(items, yamlString(strings.TrimSpace(line[1:])))
+			continue
+		}
+
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("config line %d: expected key: value", lineNumber)
+		}
+		key := yamlString(strings.TrimSpace(parts[0]))
+		if len(parents) > 0 {
+			key = parents[len(parents)-1].key + "." + key
+		}
+
+		raw := strings.TrimSpace(parts[1])
+		if raw == "" {
+			// A mapping or a list follows
+			parents = append(parents, parent{indent: indent, key: key})
+			listKey = key
+			continue
+		}
+		listKey = ""
+		config[key] = parseYAMLValue(raw)
+	}
+
+	return config, scanner.Err()
+}
+
+func parseYAMLValue(raw string) interface{} {
+	switch {
+	case strings.HasPrefix(raw, "[") && strings.HasSuffix(raw, "]"):
+		items := []string{}
+		for _, item := range strings.Split(raw[1:len(raw)-1], ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, yamlString(item))
+			}
+		}
+		return items
+	case strings.HasPrefix(raw, "\"") || strings.HasPrefix(raw, "'"):
+		return yamlString(raw)
+	case raw == "true" || raw == "false":
+		return raw == "true"
+	}
+
+	if value, err := strconv.Atoi(raw); err == nil {
+		return value
+	}
+	return raw
+}
+
+// A plain, 'single' or "double" quoted YAML string
+func yamlString(raw string) string {
+	if len(raw) >= 2 && strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") {
+		return strings.ReplaceAll(raw[1:len(raw)-1], "''", "'")
+	}
+	if value, err := strconv.Unquote(raw); err == nil && strings.HasPrefix(raw, "\"") {
+		return value
+	}
+
+	return raw
+}
+
+// Remove a trailing # comment that is not inside a quoted string
+func stripComment(line string) string {
+	inString := false
+	for i, r := range line {
+		switch {
+		case r == '"':
+			inString = !inString
+		case r == '#' && !inString:
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+func parseConfigValue(raw string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return nil, fmt.Errorf("unterminated array %s", raw)
+		}
+		items := []string{}
+		for _, item := range strings.Split(raw[1:len(raw)-1], ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			value, err := strconv.Unquote(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s in array", item)
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case strings.HasPrefix(raw, "\""):
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", raw)
+		}
+		return value, nil
+	case raw == "true" || raw == "false":
+		return raw == "true", nil
+	default:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s", raw)
+		}
+		return value, nil
+	}
+}
+
+func (c Config) String(key string, fallback string) string {
+	if value, ok := c[key].(string); ok {
+		return value
+	}
+
+	return fallback
+}
+
+func (c Config) Strings(key string) []string {
+	if value, ok := c[key].([]string); ok {
+		return value
+	}
+
+	return nil
+}
+
+// A duration written as a string like "30m", the fallback is also used when
+// the value doesn't parse
+func (c Config) Duration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(c.String(key, ""))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+func (c Config) Bool(key string, fallback bool) bool {
+	if value, ok := c[key].(bool); ok {
+		return value
+	}
+
+	return fallback
+}
+
+func (c Config) Int(key string, fallback int) int {
+	if value, ok := c[key].(int); ok {
+		return value
+	}
+
+	return fallback
+}
+
+// The names of the sections under a table, like work for [presets.work]
+func configSectionNames(config Config, table string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for key := range config {
+		if !strings.HasPrefix(key, table+".") {
+			continue
+		}
+		name := strings.TrimPrefix(key, table+".")
+		name = name[:strings.LastIndex(name, ".")+1]
+		name = strings.TrimSuffix(name, ".")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+type configKind int
+
+const (
+	configString configKind = iota
+	configInt
+	configBool
+	configDuration
+	configList
+	// Aliases are a single username or a list of them
+	configStringOrList
+)
+
+// A key config set accepts, the pattern may contain * for a name
+type configKey struct {
+	pattern  string
+	kind     configKind
+	validate func(key string, value string) error
+}
+
+var configKeys = []configKey{
+	{"defaults.provider", configString, validateProviderName},
+	{"defaults.username", configString, nil},
+	{"defaults.color", configBool, nil},
+	{"defaults.format", configString, validateOneOf("text", "json", "ndjson", "csv", "markdown", "html", "ics")},
+	{"defaults.pages", configInt, nil},
+	{"defaults.limit", configInt, nil},
+	{"defaults.preset", configString, nil},
+	{"defaults.interval", configDuration, nil},
+	{"defaults.timeout", configDuration, nil},
+	{"defaults.deadline", configDuration, nil},
+	{"defaults.retries", configInt, nil},
+	{"defaults.absolute", configBool, nil},
+	{"defaults.tz", configString, validateTimezone},
+	{"cache.ttl", configDuration, nil},
+	{"cache.max_entries", configInt, nil},
+	{"cache.backend", configString, validateOneOf("file", "memory", "redis")},
+	{"cache.file", configString, nil},
+	{"cache.url", configString, nil},
+	{"store.backend", configString, validateOneOf("none", "file", "sqlite")},
+	{"store.path", configString, nil},
+	{"retention.cache", configString, validateRetention},
+	{"retention.archive", configString, validateRetention},
+	{"retention.snapshots", configString, validateRetention},
+	{"retention.store", configString, validateRetention},
+	{"serve.listen", configString, nil},
+	{"notify.*.url", configString, nil},
+	{"notify.*.kind", configString, validateOneOf("slack", "discord")},
+	{"notify.*.types", configList, nil},
+	{"notify.*.repos", configList, nil},
+	{"notify.*.template", configString, nil},
+	{"feed.accounts", configList, nil},
+	{"dashboard.accounts", configList, nil},
+	{"dashboard.refresh", configDuration, nil},
+	{"daemon.users", configList, nil},
+	{"daemon.orgs", configList, nil},
+	{"daemon.schedule", configString, validateSchedule},
+	{"github.api_url", configString, validateGithubAPIURL},
+	{"*.hosts.*.token", configString, validateProviderSection},
+	{"*.token", configString, validateProviderSection},
+	{"*.base_url", configString, validateProviderSection},
+	{"presets.*.repos", configList, nil},
+	{"presets.*.types", configList, nil},
+	{"presets.*.timezone", configString, validateTimezone},
+	{"presets.*.exclude_bots", configBool, nil},
+	{"presets.*.exclude_types", configList, nil},
+	{"presets.*.exclude_repos", configList, nil},
+	{"aliases.*", configStringOrList, nil},
+}
+
+func validateProviderName(key string, value string) error {
+	for _, name := range providerNames() {
+		if name == value {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown provider %q, expected one of %s", value, strings.Join(providerNames(), ", "))
+}
+
+func validateGithubAPIURL(key string, value string) error {
+	_, err := github.NormalizeBaseURL(value)
+	return err
+}
+
+func validateProviderSection(key string, value string) error {
+	return validateProviderName(key, strings.SplitN(key, ".", 2)[0])
+}
+
+func validateOneOf(values ...string) func(key string, value string) error {
+	return func(key string, value string) error {
+		for _, allowed := range values {
+			if value == allowed {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("invalid value %q for %s, expected one of %s", value, key, strings.Join(values, ", "))
+	}
+}
+
+func validateRetention(key string, value string) error {
+	_, err := parseRetention(value)
+	return err
+}
+
+func validateSchedule(key string, value string) error {
+	_, err := parseSchedule(value)
+	return err
+}
+
+func validateTimezone(key string, value string) error {
+	_, err := time.LoadLocation(value)
+	return err
+}
+
+func lookupConfigKey(key string) (configKey, error) {
+	for _, known := range configKeys {
+		if matched, _ := path.Match(known.pattern, key); matched {
+			return known, nil
+		}
+	}
+
+	patterns := make([]string, 0, len(configKeys))
+	for _, known := range configKeys {
+		patterns = append(patterns, known.pattern)
+	}
+	return configKey{}, fmt.Errorf("unknown config key %q, known keys: %s", key, strings.Join(patterns, ", "))
+}
+
+// Validate the command line values of a key and convert them to the type
+// the key holds, list values can be separate arguments or comma-separated
+func coerceConfigValue(key string, values []string) (interface{}, error) {
+	known, err := lookupConfigKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	joined := strings.Join(values, " ")
+
+	kind := known.kind
+	if kind == configStringOrList {
+		kind = configString
+		if len(items) > 1 {
+			kind = configList
+		}
+	}
+	if kind != configList && len(values) != 1 {
+		return nil, fmt.Errorf("%s takes a single value", key)
+	}
+
+	var value interface{}
+	switch kind {
+	case configInt:
+		value, err = strconv.Atoi(joined)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer, got %q", key, joined)
+		}
+	case configBool:
+		value, err = strconv.ParseBool(joined)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", key, joined)
+		}
+	case configDuration:
+		_, err = time.ParseDuration(joined)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a duration like 30m, got %q", key, joined)
+		}
+		value = joined
+	case configList:
+		if items == nil {
+			items = []string{}
+		}
+		value = items
+	default:
+		value = joined
+	}
+
+	if known.validate != nil {
+		checked := []string{joined}
+		if kind == configList {
+			checked = items
+		}
+		for _, item := range checked {
+			err = known.validate(key, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return value, nil
+}
+
+func formatConfigValue(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return strconv.Quote(value)
+	case []string:
+		quoted := make([]string, 0, len(value))
+		for _, item := range value {
+			quoted = append(quoted, strconv.Quote(item))
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Split "presets.work.repos" into its section and the key inside it
+func splitConfigKey(key string) (string, string) {
+	i := strings.LastIndex(key, ".")
+	if i < 0 {
+		return "", key
+	}
+
+	return key[:i], key[i+1:]
+}
+
+// The section header of a config line, ok is false for other lines
+func configSection(line string) (string, bool) {
+	line = strings.TrimSpace(stripComment(line))
+	if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+		return strings.ReplaceAll(strings.TrimSpace(line[1:len(line)-1]), `"`, ""), true
+	}
+
+	return "", false
+}
+
+func isConfigKeyLine(line string, name string) bool {
+	parts := strings.SplitN(stripComment(line), "=", 2)
+	return len(parts) == 2 && strings.TrimSpace(parts[0]) == name
+}
+
+// Rewrite the config file lines with key set to value, or removed when value
+// is nil. Other lines and comments are kept as they are. Returns whether
+// the key was found.
+func editConfigLines(lines []string, key string, value interface{}) ([]string, bool) {
+	section, name := splitConfigKey(key)
+	current := ""
+	sectionEnd := -1
+	for i, line := range lines {
+		if header, ok := configSection(line); ok {
+			current = header
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			sectionEnd = i + 1
+		}
+		if !isConfigKeyLine(line, name) {
+			continue
+		}
+
+		if value == nil {
+			return append(lines[:i:i], lines[i+1:]...), true
+		}
+		edited := append([]string{}, lines...)
+		edited[i] = name + " = " + formatConfigValue(value)
+		return edited, true
+	}
+
+	if value == nil {
+		return lines, false
+	}
+
+	entry := name + " = " + formatConfigValue(value)
+	if sectionEnd < 0 {
+		// Keys without a section go before the first section header
+		if section == "" {
+			return append([]string{entry}, lines...), false
+		}
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		return append(lines, "["+section+"]", entry), false
+	}
+
+	edited := append([]string{}, lines[:sectionEnd]...)
+	edited = append(edited, entry)
+	return append(edited, lines[sectionEnd:]...), false
+}
+
+func readConfigLines() ([]string, error) {
+	file, err := os.ReadFile(configPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimRight(string(file), "\n"), "\n"), nil
+}
+
+func writeConfigLines(lines []string) error {
+	err := os.MkdirAll(filepath.Dir(configPath()), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath(), []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// The file config init writes, every setting commented out with its default
+const configTemplate = `# Settings of github-activity, flags and environment variables override them
+
+[defaults]
+# username = "febryansambuari"    # fetched when no username is given
+# provider = "github"
+# format = "text"                 # text, json, ndjson, csv, markdown, html or ics
+# pages = 1
+# limit = 0
+# color = true                    # false turns colors off like --no-color
+# absolute = false                # show dates instead of relative times
+# tz = "Local"
+# timeout = "10s"
+
+[github]
+# token = "ghp_..."               # or GITHUB_TOKEN
+
+[cache]
+# ttl = "10m"
+# file = "/path/to/cache.json"
+`
+
+// The YAML version of configTemplate
+const configTemplateYAML = `# Settings of github-activity, flags and environment variables override them
+
+defaults:
+  # username: febryansambuari    # fetched when no username is given
+  # provider: github
+  # format: text                 # text, json, ndjson, csv, markdown, html or ics
+  # pages: 1
+  # limit: 0
+  # color: true                  # false turns colors off like --no-color
+  # absolute: false              # show dates instead of relative times
+  # tz: Local
+  # timeout: 10s
+
+github:
+  # token: ghp_...               # or GITHUB_TOKEN
+
+cache:
+  # ttl: 10m
+  # file: /path/to/cache.json
+`
+
+// Write the config template as config.toml, or config.yaml when asYAML is set
+func initConfig(asYAML bool, force bool) (string, error) {
+	path := configFilePath("toml")
+	template := configTemplate
+	if asYAML {
+		path = configFilePath("yaml")
+		template = configTemplateYAML
+	}
+	if _, err := os.Stat(path); err == nil && !force {
+		return path, fmt.Errorf("%s already exists, pass --force to overwrite it", path)
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return path, err
+	}
+
+	return path, os.WriteFile(path, []byte(template), 0644)
+}
+
+// config init [--yaml] [--force], config get <key>, config set <key>
+// <value...>, config unset <key>
+func runConfig(args []string) {
+	flags := newFlagSet("config")
+	asYAML := flags.Bool("yaml", false, "with init, write config.yaml instead of config.toml")
+	force := flags.Bool("force", false, "with init, overwrite an existing file")
+	_ = flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) > 0 && args[0] == "init" {
+		// Flags can also follow init
+		_ = flags.Parse(args[1:])
+		path, err := initConfig(*asYAML, *force)
+		if err != nil {
+			log.Fatalf("Error writing config: %v", err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		return
+	}
+
+	if len(args) < 2 {
+		log.Fatalf("Usage: config init [--yaml] [--force] | config get <key> | config set <key> <value...> | config unset <key>")
+	}
+	action, key := args[0], args[1]
+	if (action == "set" || action == "unset") && isYAMLConfig(configPath()) {
+		log.Fatalf("config %s only edits config.toml, edit %s by hand", action, configPath())
+	}
+
+	switch action {
+	case "get":
+		config, err := loadConfig()
+		if err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		value, ok := config[key]
+		if !ok {
+			log.Fatalf("%s is not set in %s", key, configPath())
+		}
+		fmt.Println(configValueString(value))
+	case "set":
+		if len(args) < 3 {
+			log.Fatalf("Usage: config set <key> <value...>")
+		}
+		value, err := coerceConfigValue(key, args[2:])
+		if err != nil {
+			log.Fatalf("Error setting %s: %v", key, err)
+		}
+		lines, err := readConfigLines()
+		if err != nil {
+			log.Fatalf("Error reading config: %v", err)
+		}
+		lines, _ = editConfigLines(lines, key, value)
+		err = writeConfigLines(lines)
+		if err != nil {
+			log.Fatalf("Error writing config: %v", err)
+		}
+		fmt.Printf("Set %s = %s\n", key, formatConfigValue(value))
+	case "unset":
+		lines, err := readConfigLines()
+		if err != nil {
+			log.Fatalf("Error reading config: %v", err)
+		}
+		lines, found := editConfigLines(lines, key, nil)
+		if !found {
+			log.Fatalf("%s is not set in %s", key, configPath())
+		}
+		err = writeConfigLines(lines)
+		if err != nil {
+			log.Fatalf("Error writing config: %v", err)
+		}
+		fmt.Printf("Unset %s\n", key)
+	default:
+		log.Fatalf("Unknown config action %q, expected init, get, set or unset", action)
+	}
+}
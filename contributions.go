@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+const contributionsQuery = `query($login: String!, $from: DateTime!, $to: DateTime!) {
+  user(login: $login) {
+    contributionsCollection(from: $from, to: $to) {
+      totalCommitContributions
+      totalPullRequestContributions
+      totalPullRequestReviewContributions
+      totalIssueContributions
+      totalRepositoryContributions
+      restrictedContributionsCount
+      contributionCalendar {
+        totalContributions
+        weeks {
+          contributionDays {
+            date
+            contributionCount
+          }
+        }
+      }
+    }
+  }
+}`
+
+type ContributionDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"contributionCount"`
+}
+
+// Exact contribution numbers from the GraphQL contributionsCollection, the
+// events API only covers the last 90 days and misses private contributions
+type Contributions struct {
+	Commits      int `json:"totalCommitContributions"`
+	PullRequests int `json:"totalPullRequestContributions"`
+	Reviews      int `json:"totalPullRequestReviewContributions"`
+	Issues       int `json:"totalIssueContributions"`
+	Repositories int `json:"totalRepositoryContributions"`
+	Restricted   int `json:"restrictedContributionsCount"`
+	Calendar     struct {
+		Total int `json:"totalContributions"`
+		Weeks []struct {
+			Days []ContributionDay `json:"contributionDays"`
+		} `json:"weeks"`
+	} `json:"contributionCalendar"`
+}
+
+type githubGraphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Run a query against the Github GraphQL API and decode its data into v,
+// GraphQL reports errors in the body of a 200 response
+func postGithubGraphQL(token string, query string, variables map[string]interface{}, v interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(interrupted, http.MethodPost, github.GraphQLURL(githubAPIURL), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	var response githubGraphQLResponse
+	_, err = doJSONRequest(req, &response)
+	if err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		messages := make([]string, 0, len(response.Errors))
+		for _, graphQLError := range response.Errors {
+			messages = append(messages, graphQLError.Message)
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+
+	return json.Unmarshal(response.Data, v)
+}
+
+func fetchContributions(token string, username string, year int) (Contributions, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return fetchContributionsBetween(token, username, from, from.AddDate(1, 0, 0).Add(-time.Second))
+}
+
+// The contributions between two times, Github counts at most a year at once
+func fetchContributionsBetween(token string, username string, from time.Time, to time.Time) (Contributions, error) {
+	variables := map[string]interface{}{
+		"login": username,
+		"from":  from.Format(time.RFC3339),
+		"to":    to.Format(time.RFC3339),
+	}
+
+	var data struct {
+		User *struct {
+			Contributions Contributions `json:"contributionsCollection"`
+		} `json:"user"`
+	}
+	err := postGithubGraphQL(token, contributionsQuery, variables, &data)
+	if err != nil {
+		return Contributions{}, err
+	}
+	if data.User == nil {
+		return Contributions{}, fmt.Errorf("user %s not found", username)
+	}
+
+	return data.User.Contributions, nil
+}
+
+// Shades from no contribution to the busiest day
+var heatmapShades = []string{"·", "░", "▒", "▓", "█"}
+
+// The 256 color greens of the Github calendar, one per shade after the first
+var heatmapColors = []int{22, 28, 34, 46}
+
+// The days of the calendar in order
+func (c Contributions) Days() []ContributionDay {
+	var days []ContributionDay
+	for _, week := range c.Calendar.Weeks {
+		days = append(days, week.Days...)
+	}
+
+	return days
+}
+
+// One row per weekday and one column per week, like the Github profile
+func contributionHeatmap(contributions Contributions) []string {
+	return renderHeatmap(contributions.Days(), false)
+}
+
+func printContributionCounts(contributions Contributions) {
+	fmt.Printf("Total Contributions: %d\n", contributions.Calendar.Total)
+	fmt.Printf("Commits: %d\n", contributions.Commits)
+	fmt.Printf("Pull Requests: %d\n", contributions.PullRequests)
+	fmt.Printf("Reviews: %d\n", contributions.Reviews)
+	fmt.Printf("Issues: %d\n", contributions.Issues)
+	fmt.Printf("Repositories Created: %d\n", contributions.Repositories)
+	fmt.Printf("Private Contributions: %d\n", contributions.Restricted)
+}
+
+// Register --source on a command that counts the activity per day, the
+// returned function checks it once the filter is set. The events source
+// counts the events of the REST feed, which only go 90 days back. The
+// graphql source counts the contribution calendar of the Github profile
+// instead, it needs a token and can't filter by type or repository.
+func addSourceFlag(flags *flag.FlagSet) func(filter EventFilter, provider string) string {
+	source := flags.String("source", "events", "what to count: events from the events API or graphql for the contribution calendar of the profile, which needs a token")
+
+	return func(filter EventFilter, provider string) string {
+		switch *source {
+		case "events":
+		case "graphql":
+			if provider != "github" {
+				log.Fatalf("--source graphql only works with the github provider")
+			}
+			if len(filter.Types) > 0 || len(filter.Repos) > 0 || filter.ExcludeBots || len(filter.ExcludeTypes) > 0 || len(filter.ExcludeRepos) > 0 {
+				log.Fatalf("--source graphql counts contributions, it can't be combined with type or repository filters")
+			}
+		default:
+			log.Fatalf("Unknown source %q, expected events or graphql", *source)
+		}
+
+		return *source
+	}
+}
+
+// The period the contribution calendar covers: from --since, or the year up
+// to the last day like the Github profile, to the last day
+func contributionPeriod(filter EventFilter) (time.Time, time.Time) {
+	to := graphEnd(filter)
+	from := filter.Since
+	if from.IsZero() {
+		from = to.AddDate(-1, 0, 1)
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		log.Fatalf("--source graphql covers at most a year, set --since to a later date")
+	}
+
+	return from, to
+}
+
+// The token of the GraphQL API, which doesn't answer without one
+func graphQLToken(token string) string {
+	if token == "" {
+		log.Fatalf("--source graphql needs a Github token, pass --token or set GITHUB_TOKEN or github.token")
+	}
+
+	return token
+}
+
+// Render days, in order, as one row per weekday and one column per week
+// starting on Sunday. The shades scale to the busiest day, color paints them
+// in greens for terminals.
+func renderHeatmap(days []ContributionDay, color bool) []string {
+	busiest := busiestDay(days)
+
+	var weeks [][]string
+	var firstSunday time.Time
+	for _, day := range days {
+		date, err := time.Parse(dayLayout, day.Date)
+		if err != nil {
+			continue
+		}
+		if firstSunday.IsZero() {
+			firstSunday = date.AddDate(0, 0, -int(date.Weekday()))
+		}
+		week := int(date.Sub(firstSunday).Hours()/24) / 7
+		for len(weeks) <= week {
+			weeks = append(weeks, []string{" ", " ", " ", " ", " ", " ", " "})
+		}
+
+		weeks[week][date.Weekday()] = heatmapShade(heatmapLevel(day.Count, busiest), color)
+	}
+
+	lines := make([]string, 0, 7)
+	for weekday := 0; weekday < 7; weekday++ {
+		var row strings.Builder
+		for _, week := range weeks {
+			row.WriteString(week[weekday])
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", time.Weekday(weekday).String()[:3], row.String()))
+	}
+
+	return lines
+}
+
+// The shade of a day from 0 for none to 4 for the busiest
+func heatmapLevel(count int, busiest int) int {
+	if count <= 0 {
+		return 0
+	}
+
+	return 1 + (count-1)*(len(heatmapShades)-1)/busiest
+}
+
+func heatmapShade(shade int, color bool) string {
+	if !color || shade == 0 {
+		return heatmapShades[shade]
+	}
+
+	return fmt.Sprintf("\x1b[38;5;%dm%s\x1b[0m", heatmapColors[shade-1], heatmapShades[shade])
+}
+
+// contributions <user> [--year 2024] prints the exact yearly numbers and the
+// contribution calendar
+func runContributions(args []string) {
+	flags := newFlagSet("contributions")
+	year := flags.Int("year", time.Now().Year(), "year to count the contributions of")
+	token := flags.String("token", "", "Github token, the GraphQL API needs one (defaults to GITHUB_TOKEN or github.token)")
+	format := flags.String("format", "text", "output format: text or json")
+	applyTimeouts := addTimeoutFlags(flags)
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		log.Fatalf("Usage: contributions <username> [--year 2024] [--format text|json]")
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	for _, resolveErr := range []error{
+		settings.ResolveSecret("token", "GITHUB_TOKEN", "github.token"),
+	} {
+		if resolveErr != nil {
+			log.Fatalf("Error resolving settings: %v", resolveErr)
+		}
+	}
+	applyTimeouts(settings)
+	if *token == "" {
+		log.Fatalf("The GraphQL API needs a token, pass --token or set GITHUB_TOKEN")
+	}
+
+	username := expandUsername(config, flags.Arg(0))[0]
+	contributions, err := fetchContributions(*token, username, *year)
+	if err != nil {
+		exitIfInterrupted()
+		log.Fatalf("Error fetching contributions of %s: %v", username, err)
+	}
+
+	switch *format {
+	case "json":
+		err = printJSON(contributions)
+		if err != nil {
+			log.Fatalf("Error encoding contributions: %v", err)
+		}
+	case "text":
+		fmt.Printf("User: %s\n", username)
+		fmt.Printf("Year: %d\n", *year)
+		printContributionCounts(contributions)
+		fmt.Println("----------------------")
+		for _, line := range contributionHeatmap(contributions) {
+			fmt.Println(line)
+		}
+	default:
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+}
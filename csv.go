@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+var csvHeader = []string{"type", "repo", "created_at", "summary", "actor"}
+
+// Writes events as CSV rows for spreadsheets, the header row comes first
+type eventCSVWriter struct {
+	writer *csv.Writer
+}
+
+func newEventCSVWriter(w io.Writer) (*eventCSVWriter, error) {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventCSVWriter{writer: writer}, nil
+}
+
+// Write a row per event and flush them, so rows show up as the pages arrive
+func (w *eventCSVWriter) Write(events []Event) error {
+	for _, event := range events {
+		err := w.writer.Write([]string{
+			event.Type,
+			event.Repo.Name,
+			event.CreatedAt.UTC().Format(time.RFC3339),
+			describeEvent(event),
+			event.Actor.Login,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.writer.Flush()
+
+	return w.writer.Error()
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// A user or organization the daemon syncs, its breaker is kept across runs
+type daemonTarget struct {
+	name    string
+	feed    string
+	breaker *circuitBreaker
+}
+
+// Sync every target once, a target that fails is logged and the others
+// still sync. A target that keeps failing is skipped until its breaker lets
+// it retry, interval is the time between two scheduled runs.
+func syncTargets(providerName string, config ProviderConfig, targets []daemonTarget, interval time.Duration) {
+	for _, target := range targets {
+		if interrupted.Err() != nil {
+			return
+		}
+		if !target.breaker.Allow() {
+			logger.Debug("Skipping target, circuit breaker "+target.breaker.String(), "user", target.name)
+			continue
+		}
+		targetConfig := config
+		targetConfig.Feed = target.feed
+		events, total, err := syncEvents(providerName, targetConfig, target.name)
+		if err != nil {
+			if interrupted.Err() == nil {
+				logger.Error("Syncing events failed", "user", target.name, "error", err)
+				if target.breaker.Failure(interval) {
+					logger.Warn("Circuit breaker "+target.breaker.String(), "user", target.name)
+				}
+			}
+			continue
+		}
+		if target.breaker.Success() {
+			logger.Warn("Circuit breaker closed, syncing again", "user", target.name)
+		}
+		fmt.Printf("%s %s: %d new events, %d stored\n", time.Now().Format("2006-01-02 15:04:05"), target.name, len(events), total)
+	}
+}
+
+// daemon [<username>...] syncs the users and organizations of the daemon
+// section on a schedule until interrupted, posting the new events with
+// --notify and --notify-desktop
+func runDaemon(args []string) {
+	flags := newFlagSet("daemon")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	token := flags.String("token", "", "access token for the provider (defaults to GITHUB_TOKEN, GITLAB_TOKEN, GITEA_TOKEN or BITBUCKET_TOKEN)")
+	baseURL := flags.String("base-url", "", "base URL of a self-hosted provider instance")
+	pages := flags.Int("pages", github.MaxEventPages, "maximum number of pages to fetch, paging stops earlier at the stored events")
+	scheduleValue := flags.String("schedule", "15m", "when to sync: an interval like 15m or a crontab line like \"*/15 * * * *\"")
+	var orgs stringList
+	flags.Var(&orgs, "org", "organization to sync, can be repeated (default daemon.orgs)")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyStore := addStoreFlags(flags, "file")
+	applyNotify := addNotifyFlag(flags)
+	flags.BoolVar(&desktopNotifications, "notify-desktop", false, "show a desktop notification for the new events")
+	_ = flags.Parse(args)
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	err = settings.Resolve("provider", "GITHUB_ACTIVITY_PROVIDER", "defaults.provider")
+	if err != nil {
+		log.Fatalf("Error resolving settings: %v", err)
+	}
+	envPrefix := providerEnvPrefix(*providerName)
+	for _, resolveErr := range []error{
+		settings.ResolveSecret("token", envPrefix+"_TOKEN", *providerName+".token"),
+		settings.Resolve("base-url", envPrefix+"_BASE_URL", *providerName+".base_url"),
+		settings.Resolve("schedule", "GITHUB_ACTIVITY_SCHEDULE", "daemon.schedule"),
+	} {
+		if resolveErr != nil {
+			log.Fatalf("Error resolving settings: %v", resolveErr)
+		}
+	}
+	applyTimeouts(settings)
+	applyStore(settings)
+	applyNotify(config)
+	if eventStore == nil {
+		log.Fatalf("daemon keeps the events in the store, --store can't be none")
+	}
+	if desktopNotifications {
+		err = checkDesktopNotifications()
+		if err != nil {
+			log.Fatalf("Error enabling desktop notifications: %v", err)
+		}
+	}
+	when, err := parseSchedule(*scheduleValue)
+	if err != nil {
+		log.Fatalf("Error parsing --schedule: %v", err)
+	}
+
+	usernames := flags.Args()
+	if len(usernames) == 0 && len(orgs) == 0 {
+		usernames = config.Strings("daemon.users")
+		orgs = config.Strings("daemon.orgs")
+	}
+	var targets []daemonTarget
+	for _, username := range uniqueStrings(expandUsernames(config, usernames)) {
+		targets = append(targets, daemonTarget{name: username, feed: FeedUser, breaker: newCircuitBreaker()})
+	}
+	for _, org := range uniqueStrings(orgs) {
+		targets = append(targets, daemonTarget{name: org, feed: FeedOrg, breaker: newCircuitBreaker()})
+	}
+	if len(targets) == 0 {
+		log.Fatalf("Nothing to sync, pass usernames or --org, or set daemon.users or daemon.orgs in %s", configPath())
+	}
+	providerConfig := ProviderConfig{Token: *token, BaseURL: *baseURL, Pages: *pages}
+	// Fail now on a provider without organization feeds instead of every run
+	for _, target := range targets {
+		_, err = newProvider(*providerName, ProviderConfig{Feed: target.feed})
+		if err != nil {
+			log.Fatalf("Error configuring provider: %v", err)
+		}
+	}
+
+	cancelOnInterrupt()
+	logger.Info("Daemon started", "targets", len(targets), "schedule", *scheduleValue)
+	for {
+		first := when.Next(time.Now())
+		syncTargets(*providerName, providerConfig, targets, when.Next(first).Sub(first))
+
+		next := when.Next(time.Now())
+		logger.Info("Next sync", "at", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-interrupted.Done():
+			timer.Stop()
+		}
+		if interrupted.Err() != nil {
+			// Every event synced is already in the store
+			logger.Info("Daemon stopped")
+			return
+		}
+	}
+}
@@ -0,0 +1,783 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dashboardSidebarWidth = 32
+
+// Repositories listed in the sidebar, the others are counted together
+const dashboardSidebarRepos = 8
+
+type dashboardPane struct {
+	account  Account
+	provider Provider
+	events   []Event
+	err      error
+	loading  bool
+	breaker  *circuitBreaker
+}
+
+// Time windows cycled with + and -, zero shows everything
+var dashboardWindows = []time.Duration{0, time.Hour, 6 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+type dashboard struct {
+	mutex       sync.Mutex
+	panes       []*dashboardPane
+	refresh     time.Duration
+	refreshedAt time.Time
+	updates     chan struct{}
+
+	// View state changed with keystrokes, applied when rendering
+	hiddenTypes map[string]bool
+	selected    int
+	single      bool
+	window      int
+
+	// Fuzzy search started with /, typing only edits the query while searching
+	searching bool
+	query     string
+
+	// Event under the cursor in the selected pane and its opened details
+	cursor int
+	detail *eventDetailView
+
+	// User picker opened with p, adds a Github pane for the chosen login
+	picker *userPicker
+}
+
+type userPicker struct {
+	query       string
+	suggestions []string
+	selected    int
+}
+
+type eventDetailView struct {
+	lines   []string
+	loading bool
+	err     error
+	offset  int
+}
+
+func newDashboard(accounts []Account, refresh time.Duration) (*dashboard, error) {
+	d := &dashboard{
+		refresh:     refresh,
+		updates:     make(chan struct{}, 1),
+		hiddenTypes: make(map[string]bool),
+	}
+	for _, account := range accounts {
+		provider, err := newProvider(account.Provider, ProviderConfig{Token: account.Token, BaseURL: account.BaseURL, Feed: account.Feed})
+		if err != nil {
+			return nil, err
+		}
+		d.panes = append(d.panes, &dashboardPane{account: account, provider: provider, breaker: newCircuitBreaker()})
+	}
+
+	return d, nil
+}
+
+// Reload every pane in the background, force skips the cache
+func (d *dashboard) load(force bool) {
+	d.mutex.Lock()
+	d.refreshedAt = time.Now()
+	d.mutex.Unlock()
+
+	for _, pane := range d.panes {
+		go d.loadPane(pane, force)
+	}
+}
+
+func (d *dashboard) loadPane(pane *dashboardPane, force bool) {
+	d.mutex.Lock()
+	// Panes that keep failing are skipped until their breaker lets them retry
+	if !pane.breaker.Allow() {
+		d.mutex.Unlock()
+		return
+	}
+	pane.loading = true
+	d.mutex.Unlock()
+	d.notify()
+
+	var events []Event
+	var err error
+	if force {
+		events, err = refreshEvents(pane.provider, pane.account.Username)
+	} else {
+		events, err = getEvents(pane.provider, pane.account.Username)
+	}
+
+	d.mutex.Lock()
+	pane.loading = false
+	pane.err = err
+	if err == nil {
+		pane.events = events
+		pane.breaker.Success()
+	} else {
+		pane.breaker.Failure(d.refresh)
+	}
+	d.mutex.Unlock()
+	d.notify()
+}
+
+// Ask for a redraw without blocking when one is already pending
+func (d *dashboard) notify() {
+	select {
+	case d.updates <- struct{}{}:
+	default:
+	}
+}
+
+// The events of a pane that pass the type filter and time window
+func (d *dashboard) visibleEvents(pane *dashboardPane) []Event {
+	var events []Event
+	window := dashboardWindows[d.window]
+	for _, event := range pane.events {
+		if d.hiddenTypes[event.Type] {
+			continue
+		}
+		if window > 0 && time.Since(event.CreatedAt) > window {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// The panes shown on screen, only the selected one in single user view
+func (d *dashboard) visiblePanes() []*dashboardPane {
+	if d.single {
+		return d.panes[d.selected : d.selected+1]
+	}
+
+	return d.panes
+}
+
+// Every event type loaded, in the order used by the number keys
+func (d *dashboard) eventTypes() []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, pane := range d.panes {
+		for _, event := range pane.events {
+			if !seen[event.Type] {
+				seen[event.Type] = true
+				types = append(types, event.Type)
+			}
+		}
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// Apply a view key, returns false when the key isn't a view key
+func (d *dashboard) handleKey(key string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.detail != nil {
+		switch key {
+		case "esc", "backspace", "left":
+			d.detail = nil
+		case "up":
+			if d.detail.offset > 0 {
+				d.detail.offset--
+			}
+		case "down":
+			if d.detail.offset < len(d.detail.lines)-1 {
+				d.detail.offset++
+			}
+		case "q", "ctrl+c":
+			return false
+		}
+		return true
+	}
+
+	if d.picker != nil {
+		d.handlePickerKey(key)
+		return true
+	}
+
+	if d.searching {
+		switch key {
+		case "enter":
+			d.searching = false
+		case "esc", "ctrl+c":
+			d.searching = false
+			d.query = ""
+			d.cursor = 0
+		case "backspace":
+			if query := []rune(d.query); len(query) > 0 {
+				d.query = string(query[:len(query)-1])
+			}
+		default:
+			if len([]rune(key)) == 1 {
+				d.query += key
+			}
+		}
+		d.cursor = 0
+		return true
+	}
+
+	switch key {
+	case "/":
+		d.searching = true
+	case "esc":
+		d.query = ""
+	case "tab", "right":
+		d.selected = (d.selected + 1) % len(d.panes)
+		d.cursor = 0
+	case "left":
+		d.selected = (d.selected + len(d.panes) - 1) % len(d.panes)
+		d.cursor = 0
+	case "down":
+		if d.cursor < len(d.listedEvents(d.panes[d.selected]))-1 {
+			d.cursor++
+		}
+	case "up":
+		if d.cursor > 0 {
+			d.cursor--
+		}
+	case "enter":
+		d.openDetail()
+	case "b":
+		d.toggleBookmark()
+	case "o":
+		d.openInBrowser(eventHTMLURL)
+	case "O":
+		d.openInBrowser(repoHTMLURL)
+	case "u":
+		d.single = !d.single
+	case "p":
+		d.picker = &userPicker{}
+		d.suggestUsernames()
+	case "+":
+		if d.window < len(dashboardWindows)-1 {
+			d.window++
+			d.cursor = 0
+		}
+	case "-":
+		if d.window > 0 {
+			d.window--
+			d.cursor = 0
+		}
+	case "a":
+		d.hiddenTypes = make(map[string]bool)
+		d.cursor = 0
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		types := d.eventTypes()
+		index := int(key[0] - '1')
+		if index < len(types) {
+			d.hiddenTypes[types[index]] = !d.hiddenTypes[types[index]]
+			d.cursor = 0
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+func formatWindow(window time.Duration) string {
+	switch {
+	case window == 0:
+		return "all"
+	case window%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	default:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	}
+}
+
+func (d *dashboard) render(rows int, cols int) []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	sidebarWidth := dashboardSidebarWidth
+	if cols < 80 {
+		sidebarWidth = 0
+	}
+	mainWidth := cols
+	if sidebarWidth > 0 {
+		mainWidth = cols - sidebarWidth - 1
+	}
+
+	nextRefresh := time.Until(d.refreshedAt.Add(d.refresh)).Round(time.Second)
+	header := fmt.Sprintf(" github-activity dashboard | window %s | refreshed %s, next in %s | 1-9 types, a all, +/- window, tab user, u single, p add user, / search, up/down enter details, b bookmark, o/O open event/repo, r refresh, q quit",
+		formatWindow(dashboardWindows[d.window]), d.refreshedAt.Format("15:04:05"), nextRefresh)
+	if d.picker != nil {
+		header = fmt.Sprintf(" add user: %s█  (up/down pick, enter add, esc cancel)", d.picker.query)
+	} else if d.searching || d.query != "" {
+		header = fmt.Sprintf(" /%s", d.query)
+		if d.searching {
+			header += "█  (enter keep, esc clear)"
+		}
+	}
+	lines := []string{reverseVideo(fitWidth(header, cols))}
+
+	left := d.renderPanes(rows-1, mainWidth)
+	right := d.renderSidebar(rows - 1)
+	for i := 0; i < rows-1; i++ {
+		line := left[i]
+		if sidebarWidth > 0 {
+			sidebarLine := ""
+			if i < len(right) {
+				sidebarLine = right[i]
+			}
+			line += "│" + fitWidth(sidebarLine, sidebarWidth)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// Split the height between the panes, each with a title and its newest events
+// An event as listed in a pane, with the runes matched by the search
+type listedEvent struct {
+	event     Event
+	text      string
+	positions []int
+}
+
+// The events of a pane after the filters and the search, in display order
+func (d *dashboard) listedEvents(pane *dashboardPane) []listedEvent {
+	var listed []listedEvent
+	for _, event := range d.visibleEvents(pane) {
+		// Bookmarked events are starred, the mark is not part of the search
+		mark := "  "
+		if isBookmarked(event.ID) {
+			mark = "★ "
+		}
+
+		if d.query == "" {
+			listed = append(listed, listedEvent{event: event, text: mark + formatEventLine(event)})
+			continue
+		}
+		text := eventSearchText(event)
+		positions, _, ok := fuzzyMatch(d.query, text)
+		if ok {
+			for i := range positions {
+				positions[i] += len([]rune(mark))
+			}
+			listed = append(listed, listedEvent{event: event, text: mark + text, positions: positions})
+		}
+	}
+
+	return listed
+}
+
+// Split the height between the panes, each with a title and its newest events
+func (d *dashboard) renderPanes(height int, width int) []string {
+	if d.picker != nil {
+		return d.renderPicker(height, width)
+	}
+	if d.detail != nil {
+		return d.renderDetail(height, width)
+	}
+
+	var lines []string
+	panes := d.visiblePanes()
+	for i, pane := range panes {
+		paneHeight := height / len(panes)
+		if i == len(panes)-1 {
+			paneHeight = height - len(lines)
+		}
+		if paneHeight <= 0 {
+			continue
+		}
+
+		listed := d.listedEvents(pane)
+		selected := pane == d.panes[d.selected]
+		title := fmt.Sprintf(" %s:%s (%d events)", pane.account.Provider, pane.account.Username, len(listed))
+		if selected && len(d.panes) > 1 {
+			title = " >" + title[1:]
+		}
+		if pane.loading {
+			title += " loading..."
+		}
+		lines = append(lines, reverseVideo(fitWidth(title, width)))
+
+		var body []string
+		if pane.err != nil {
+			body = append(body, fitWidth("error: "+pane.err.Error(), width))
+		}
+		// Scroll so the cursor stays visible in the selected pane
+		offset := 0
+		if selected && d.cursor > paneHeight-2 {
+			offset = d.cursor - (paneHeight - 2)
+		}
+		for j, item := range listed {
+			if j < offset {
+				continue
+			}
+			line := highlightMatches(item.text, item.positions, width)
+			if selected && j == d.cursor {
+				line = reverseVideo(fitWidth(item.text, width))
+			}
+			body = append(body, line)
+		}
+		for j := 0; j < paneHeight-1; j++ {
+			line := fitWidth("", width)
+			if j < len(body) {
+				line = body[j]
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func (d *dashboard) renderDetail(height int, width int) []string {
+	title := " Event details (up/down scroll, esc back)"
+	if d.detail.loading {
+		title += " loading..."
+	}
+	lines := []string{reverseVideo(fitWidth(title, width))}
+
+	body := d.detail.lines
+	if d.detail.err != nil {
+		body = append([]string{"error: " + d.detail.err.Error(), ""}, body...)
+	}
+	for j := 0; j < height-1; j++ {
+		line := ""
+		if d.detail.offset+j < len(body) {
+			line = body[d.detail.offset+j]
+		}
+		lines = append(lines, fitWidth(line, width))
+	}
+
+	return lines
+}
+
+// Star or unstar the event under the cursor
+func (d *dashboard) toggleBookmark() {
+	listed := d.listedEvents(d.panes[d.selected])
+	if d.cursor >= len(listed) {
+		return
+	}
+
+	toggleBookmark(listed[d.cursor].event)
+	err := saveArchive()
+	if err != nil {
+		d.panes[d.selected].err = fmt.Errorf("saving bookmark: %v", err)
+	}
+}
+
+// Open the page of the event under the cursor in the browser, the browser
+// is started in the background so the dashboard keeps drawing
+func (d *dashboard) openInBrowser(pageURL func(Event) string) {
+	listed := d.listedEvents(d.panes[d.selected])
+	if d.cursor >= len(listed) {
+		return
+	}
+
+	pane := d.panes[d.selected]
+	event := listed[d.cursor].event
+	go func() {
+		err := openBrowser(pageURL(event))
+		if err != nil {
+			d.mutex.Lock()
+			pane.err = fmt.Errorf("opening browser: %v", err)
+			d.mutex.Unlock()
+			d.notify()
+		}
+	}()
+}
+
+// Open the detail view of the event under the cursor, the linked issue,
+// pull request or commit is fetched in the background
+func (d *dashboard) openDetail() {
+	listed := d.listedEvents(d.panes[d.selected])
+	if d.cursor >= len(listed) {
+		return
+	}
+
+	event := listed[d.cursor].event
+	view := &eventDetailView{
+		lines:   EventDetails{Event: event, HTMLURL: eventHTMLURL(event)}.Lines(),
+		loading: true,
+	}
+	d.detail = view
+
+	go func() {
+		details, err := fetchEventDetails(event)
+		d.mutex.Lock()
+		view.loading = false
+		view.err = err
+		view.lines = details.Lines()
+		d.mutex.Unlock()
+		d.notify()
+	}()
+}
+
+// Edit the picker query or pick a suggestion, the suggestions are refreshed
+// in the background after every edit
+func (d *dashboard) handlePickerKey(key string) {
+	picker := d.picker
+	switch key {
+	case "esc", "ctrl+c":
+		d.picker = nil
+	case "up":
+		if picker.selected > 0 {
+			picker.selected--
+		}
+	case "down":
+		if picker.selected < len(picker.suggestions)-1 {
+			picker.selected++
+		}
+	case "enter":
+		username := picker.query
+		if picker.selected < len(picker.suggestions) {
+			username = picker.suggestions[picker.selected]
+		}
+		d.picker = nil
+		if username != "" {
+			d.addPane(Account{Provider: "github", Username: username})
+		}
+	case "backspace":
+		if query := []rune(picker.query); len(query) > 0 {
+			picker.query = string(query[:len(query)-1])
+			d.suggestUsernames()
+		}
+	default:
+		if len([]rune(key)) == 1 {
+			picker.query += key
+			d.suggestUsernames()
+		}
+	}
+}
+
+// Complete the picker query, results for an outdated query are dropped
+func (d *dashboard) suggestUsernames() {
+	picker := d.picker
+	query := picker.query
+	go func() {
+		suggestions := completeUsernames(query)
+		d.mutex.Lock()
+		if d.picker == picker && picker.query == query {
+			picker.suggestions = suggestions
+			picker.selected = 0
+		}
+		d.mutex.Unlock()
+		d.notify()
+	}()
+}
+
+// Add a pane for the account, select it and load its events
+func (d *dashboard) addPane(account Account) {
+	for i, pane := range d.panes {
+		if pane.account.Provider == account.Provider && pane.account.Username == account.Username && pane.account.BaseURL == account.BaseURL {
+			d.selected = i
+			d.cursor = 0
+			return
+		}
+	}
+
+	provider, err := newProvider(account.Provider, ProviderConfig{Token: account.Token, BaseURL: account.BaseURL, Feed: account.Feed})
+	if err != nil {
+		return
+	}
+	pane := &dashboardPane{account: account, provider: provider, breaker: newCircuitBreaker()}
+	d.panes = append(d.panes, pane)
+	d.selected = len(d.panes) - 1
+	d.cursor = 0
+	go d.loadPane(pane, false)
+}
+
+func (d *dashboard) renderPicker(height int, width int) []string {
+	lines := []string{fitWidth(" Suggestions", width)}
+	for i, username := range d.picker.suggestions {
+		line := fitWidth("   "+username, width)
+		if i == d.picker.selected {
+			line = reverseVideo(line)
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) < height {
+		lines = append(lines, fitWidth("", width))
+	}
+
+	return lines[:height]
+}
+
+func (d *dashboard) renderSidebar(height int) []string {
+	perType := make(map[string]int)
+	perRepo := make(map[string]int)
+	total := 0
+	for _, pane := range d.visiblePanes() {
+		for _, event := range d.visibleEvents(pane) {
+			perType[event.Type]++
+			perRepo[event.Repo.Name]++
+			total++
+		}
+	}
+
+	lines := []string{" Stats", fmt.Sprintf(" Total events: %d", total), "", " Types"}
+	for i, eventType := range d.eventTypes() {
+		check := "x"
+		if d.hiddenTypes[eventType] {
+			check = " "
+		}
+		lines = append(lines, fmt.Sprintf(" [%s] %d %s: %d", check, i+1, eventType, perType[eventType]))
+	}
+
+	// Busiest repositories first, ties by name
+	repos := make([]string, 0, len(perRepo))
+	for repo := range perRepo {
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if perRepo[repos[i]] != perRepo[repos[j]] {
+			return perRepo[repos[i]] > perRepo[repos[j]]
+		}
+		return repos[i] < repos[j]
+	})
+	lines = append(lines, "", " Repositories")
+	others := 0
+	for i, repo := range repos {
+		if i >= dashboardSidebarRepos {
+			others += perRepo[repo]
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(" %s: %d", repo, perRepo[repo]))
+	}
+	if others > 0 {
+		lines = append(lines, fmt.Sprintf(" %d others: %d", len(repos)-dashboardSidebarRepos, others))
+	}
+
+	lines = append(lines, "", " Panes")
+	for _, pane := range d.panes {
+		status := fmt.Sprintf("%d", len(pane.events))
+		if pane.breaker.Open() {
+			status = "breaker " + pane.breaker.String()
+		} else if pane.err != nil {
+			status = "error"
+		}
+		lines = append(lines, fmt.Sprintf(" %s: %s", pane.account.Username, status))
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return lines
+}
+
+// One line summary of an event for lists
+func formatEventLine(event Event) string {
+	location := displayLocation
+	if location == nil {
+		location = time.Local
+	}
+	line := fmt.Sprintf("%s  %s", event.CreatedAt.In(location).Format("01-02 15:04"), describeEvent(event))
+	if event.Target.Title != "" && event.Action != ActionForked && event.Action != ActionJoined {
+		line += "  " + event.Target.Title
+	}
+
+	return line
+}
+
+func runDashboard(args []string) {
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	flags := newFlagSet("dashboard")
+	var accountValues stringList
+	flags.Var(&accountValues, "account", "account as provider:username[@base-url], can be repeated (default dashboard.accounts)")
+	refresh := flags.Duration("refresh", 0, "auto-refresh interval (default dashboard.refresh or 5m)")
+	applyTimeDisplay := addTimeDisplayFlags(flags)
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	_ = flags.Parse(args)
+
+	if len(accountValues) == 0 {
+		accountValues = config.Strings("dashboard.accounts")
+	}
+	if len(accountValues) == 0 {
+		log.Fatalf("No accounts to show, pass --account or set dashboard.accounts in %s", configPath())
+	}
+	if *refresh == 0 {
+		*refresh, err = time.ParseDuration(config.String("dashboard.refresh", "5m"))
+		if err != nil {
+			log.Fatalf("Error parsing dashboard.refresh: %v", err)
+		}
+	}
+
+	settings := newSettingsResolver(flags, config)
+	applyTimeDisplay(settings)
+	applyTimeouts(settings)
+	applyCache(settings)
+	accounts, err := parseAccounts(config, accountValues)
+	if err != nil {
+		log.Fatalf("Error parsing account: %v", err)
+	}
+
+	d, err := newDashboard(accounts, *refresh)
+	if err != nil {
+		log.Fatalf("Error configuring dashboard: %v", err)
+	}
+
+	logger.SetOutput(io.Discard)
+	loadCache()
+	err = loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		log.Fatalf("Error opening terminal: %v", err)
+	}
+
+	keys := make(chan string)
+	go term.ReadKeys(keys)
+
+	autoRefresh := time.NewTicker(*refresh)
+	defer autoRefresh.Stop()
+	clock := time.NewTicker(time.Second)
+	defer clock.Stop()
+
+	d.load(false)
+
+loop:
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				break loop
+			}
+			if d.handleKey(key) {
+				break
+			}
+			switch strings.ToLower(key) {
+			case "q", "ctrl+c":
+				break loop
+			case "r":
+				d.load(true)
+			}
+		case <-d.updates:
+		case <-autoRefresh.C:
+			d.load(true)
+		case <-clock.C:
+		case <-interrupted.Done():
+			break loop
+		}
+
+		term.Draw(d.render(term.Size()))
+	}
+
+	term.Close()
+
+	// Save the cache before exiting
+	saveCache()
+	exitIfInterrupted()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Words used in sentences for the kinds of target
+var targetNouns = map[string]string{
+	TargetIssue:       "issue",
+	TargetPullRequest: "pull request",
+	TargetRelease:     "release",
+	TargetComment:     "commit",
+}
+
+// Render an event as a sentence like "Pushed 3 commits to main in
+// owner/repo" or "Opened issue #42 in owner/repo", from the normalized
+// action and target so it reads the same for every provider
+func describeEvent(event Event) string {
+	repo := event.Repo.Name
+	target := targetNouns[event.Target.Kind]
+	if target == "" {
+		target = event.Target.Kind
+	}
+	if event.Target.Number > 0 {
+		target += fmt.Sprintf(" #%d", event.Target.Number)
+	}
+
+	switch event.Action {
+	case ActionPushed:
+		commits := event.Size
+		if commits < len(event.Commits) {
+			commits = len(event.Commits)
+		}
+		pushed := "Pushed"
+		if commits > 0 {
+			pushed += fmt.Sprintf(" %d %s", commits, plural(commits, "commit"))
+		}
+		if event.Ref != "" {
+			return fmt.Sprintf("%s to %s in %s", pushed, event.Ref, repo)
+		}
+		return fmt.Sprintf("%s to %s", pushed, repo)
+	case ActionCreated, ActionDeleted:
+		if event.Target.Kind == TargetRepository || event.Ref == "" {
+			return fmt.Sprintf("%s repository %s", capitalize(event.Action), repo)
+		}
+		return fmt.Sprintf("%s %s %s in %s", capitalize(event.Action), event.Target.Kind, event.Ref, repo)
+	case ActionOpened, ActionClosed, ActionReopened, ActionMerged:
+		if event.Target.Kind == TargetRepository {
+			// Github reports a repository made public as opened
+			return fmt.Sprintf("Made %s public", repo)
+		}
+		return fmt.Sprintf("%s %s in %s", capitalize(event.Action), target, repo)
+	case ActionCommented:
+		if event.Target.Kind == TargetComment {
+			return fmt.Sprintf("Commented on a commit in %s", repo)
+		}
+		return fmt.Sprintf("Commented on %s in %s", target, repo)
+	case ActionReviewed:
+		return fmt.Sprintf("Reviewed %s in %s", target, repo)
+	case ActionStarred:
+		return fmt.Sprintf("Starred %s", repo)
+	case ActionForked:
+		if event.Target.Title != "" {
+			return fmt.Sprintf("Forked %s to %s", repo, event.Target.Title)
+		}
+		return fmt.Sprintf("Forked %s", repo)
+	case ActionReleased:
+		if event.Ref != "" {
+			return fmt.Sprintf("Released %s of %s", event.Ref, repo)
+		}
+		return fmt.Sprintf("Published a release of %s", repo)
+	case ActionJoined:
+		if event.Target.Title != "" {
+			return fmt.Sprintf("Added %s to %s", event.Target.Title, repo)
+		}
+		return fmt.Sprintf("Joined %s", repo)
+	}
+
+	if repo == "" {
+		return event.Type
+	}
+	return fmt.Sprintf("%s in %s", event.Type, repo)
+}
+
+func plural(count int, noun string) string {
+	if count == 1 {
+		return noun
+	}
+	return noun + "s"
+}
+
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Show a notification for the new events of follow mode, set with
+// --notify-desktop
+var desktopNotifications bool
+
+// More new events than this are announced by a single notification
+const maxDesktopNotifications = 5
+
+// The toast of Windows
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:GITHUB_ACTIVITY_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:GITHUB_ACTIVITY_MESSAGE)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('github-activity-cli').Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+
+// The command showing a notification on this platform: notify-send, which
+// talks to the D-Bus notification daemon, osascript on macOS and a
+// PowerShell toast on Windows. The scripts of the last two read the title
+// and message from the environment.
+func desktopNotifyCommand(title string, message string) (string, []string) {
+	switch runtime.GOOS {
+	case "darwin":
+		return "osascript", []string{"-e", `display notification (system attribute "GITHUB_ACTIVITY_MESSAGE") with title (system attribute "GITHUB_ACTIVITY_TITLE")`}
+	case "windows":
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", windowsToastScript}
+	default:
+		return "notify-send", []string{"--app-name", "github-activity-cli", "--", title, message}
+	}
+}
+
+// Check that notifications can be shown before following
+func checkDesktopNotifications() error {
+	name, _ := desktopNotifyCommand("", "")
+	_, err := exec.LookPath(name)
+	if err != nil {
+		return fmt.Errorf("desktop notifications need %s: %v", name, err)
+	}
+
+	return nil
+}
+
+func showDesktopNotification(title string, message string) error {
+	name, args := desktopNotifyCommand(title, message)
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), "GITHUB_ACTIVITY_TITLE="+title, "GITHUB_ACTIVITY_MESSAGE="+message)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v %s", name, err, output)
+	}
+
+	return nil
+}
+
+// Show a notification per new event, or one for all of them when there are
+// many. A failing notification is reported and following goes on.
+func notifyDesktop(events []Event) {
+	if !desktopNotifications || len(events) == 0 {
+		return
+	}
+
+	if len(events) > maxDesktopNotifications {
+		err := showDesktopNotification("github-activity", fmt.Sprintf("%d new events, the latest: %s", len(events), describeEvent(events[len(events)-1])))
+		if err != nil {
+			logger.Warn("Showing notification failed", "error", err)
+		}
+		return
+	}
+	for _, event := range events {
+		err := showDesktopNotification(event.Actor.Login, describeEvent(event))
+		if err != nil {
+			logger.Warn("Showing notification failed", "event", event.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// The issue, pull request or commit an event points to
+type LinkedItem struct {
+	Kind         string `json:"kind"`
+	Title        string `json:"title"`
+	State        string `json:"state,omitempty"`
+	Author       string `json:"author,omitempty"`
+	Body         string `json:"body,omitempty"`
+	Merged       bool   `json:"merged,omitempty"`
+	Comments     int    `json:"comments,omitempty"`
+	Additions    int    `json:"additions,omitempty"`
+	Deletions    int    `json:"deletions,omitempty"`
+	ChangedFiles int    `json:"changed_files,omitempty"`
+	HTMLURL      string `json:"html_url"`
+}
+
+type EventDetails struct {
+	Event   Event
+	HTMLURL string
+	Linked  *LinkedItem
+}
+
+type githubLinkedResponse struct {
+	Title        string `json:"title"`
+	State        string `json:"state"`
+	Body         string `json:"body"`
+	Merged       bool   `json:"merged"`
+	Comments     int    `json:"comments"`
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+	ChangedFiles int    `json:"changed_files"`
+	HTMLURL      string `json:"html_url"`
+	User         struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	// Only set for commits
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commit"`
+	Stats struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+	Files []json.RawMessage `json:"files"`
+}
+
+func isGithubEvent(event Event) bool {
+	// Events cached before providers existed have no provider
+	return event.Provider == "github" || event.Provider == ""
+}
+
+// The page of the repository of the event on the forge website
+func repoHTMLURL(event Event) string {
+	if !isGithubEvent(event) {
+		return event.Repo.URL
+	}
+
+	// A Github Enterprise Server serves the API of its repositories at
+	// /api/v3/repos/owner/name and their pages at /owner/name
+	if i := strings.Index(event.Repo.URL, "/api/v3/repos/"); i >= 0 {
+		return event.Repo.URL[:i] + "/" + event.Repo.URL[i+len("/api/v3/repos/"):]
+	}
+
+	return "https://github.com/" + event.Repo.Name
+}
+
+// The page of the event on the forge website, the API URL of the repository
+// is turned into its web URL when the event has no better link
+func eventHTMLURL(event Event) string {
+	if event.Target.URL != "" {
+		return event.Target.URL
+	}
+
+	if !isGithubEvent(event) {
+		return event.Repo.URL
+	}
+
+	repoURL := repoHTMLURL(event)
+	if event.Type == "PushEvent" && len(event.Commits) > 0 {
+		return repoURL + "/commit/" + event.Commits[len(event.Commits)-1].SHA
+	}
+	if (event.Type == "CreateEvent" || event.Type == "ReleaseEvent") && event.Target.Kind == TargetTag && event.Ref != "" {
+		return repoURL + "/releases/tag/" + event.Ref
+	}
+	if event.Target.Kind == TargetBranch && event.Ref != "" {
+		return repoURL + "/tree/" + event.Ref
+	}
+
+	return repoURL
+}
+
+// Fetch the issue, pull request or last pushed commit of an event. Only
+// Github events are linked, other providers get the event and its URL.
+func fetchEventDetails(event Event) (EventDetails, error) {
+	details := EventDetails{Event: event, HTMLURL: eventHTMLURL(event)}
+	if !isGithubEvent(event) {
+		return details, nil
+	}
+	details.Event = verifyEventCommits([]Event{event})[0]
+
+	repoAPI := "repos/" + event.Repo.Name
+	var kind, linkedUrl string
+	switch {
+	case event.Target.Kind == TargetPullRequest && event.Target.Number > 0:
+		kind = "pull request"
+		linkedUrl = fmt.Sprintf("%s/pulls/%d", repoAPI, event.Target.Number)
+	case event.Target.Kind == TargetIssue && event.Target.Number > 0:
+		kind = "issue"
+		linkedUrl = fmt.Sprintf("%s/issues/%d", repoAPI, event.Target.Number)
+	case event.Type == "PushEvent" && len(event.Commits) > 0:
+		kind = "commit"
+		linkedUrl = fmt.Sprintf("%s/commits/%s", repoAPI, event.Commits[len(event.Commits)-1].SHA)
+	default:
+		return details, nil
+	}
+
+	var response githubLinkedResponse
+	err := getGithubJSON(linkedUrl, &response)
+	if err != nil {
+		return details, err
+	}
+
+	linked := &LinkedItem{
+		Kind:         kind,
+		Title:        response.Title,
+		State:        response.State,
+		Author:       response.User.Login,
+		Body:         response.Body,
+		Merged:       response.Merged,
+		Comments:     response.Comments,
+		Additions:    response.Additions,
+		Deletions:    response.Deletions,
+		ChangedFiles: response.ChangedFiles,
+		HTMLURL:      response.HTMLURL,
+	}
+	if kind == "commit" {
+		linked.Title = strings.SplitN(response.Commit.Message, "\n", 2)[0]
+		linked.Body = response.Commit.Message
+		linked.Author = response.Commit.Author.Name
+		linked.Additions = response.Stats.Additions
+		linked.Deletions = response.Stats.Deletions
+		linked.ChangedFiles = len(response.Files)
+	}
+	details.Linked = linked
+	if linked.HTMLURL != "" {
+		details.HTMLURL = linked.HTMLURL
+	}
+
+	return details, nil
+}
+
+// Render the details as text lines, used by show and the dashboard
+func (details EventDetails) Lines() []string {
+	event := details.Event
+	lines := []string{
+		fmt.Sprintf("ID: %s", event.ID),
+		fmt.Sprintf("Type: %s", event.Type),
+		fmt.Sprintf("Action: %s %s", event.Action, event.Target.Kind),
+		fmt.Sprintf("Actor Login: %s", event.Actor.Login),
+		fmt.Sprintf("Repo Name: %s", event.Repo.Name),
+		fmt.Sprintf("Created At: %s", event.CreatedAt.Format("2006-01-02 15:04:05")),
+		fmt.Sprintf("URL: %s", details.HTMLURL),
+	}
+	if event.Ref != "" {
+		lines = append(lines, fmt.Sprintf("Ref: %s", event.Ref))
+	}
+	for _, commit := range event.Commits {
+		lines = append(lines, formatCommit(commit))
+	}
+
+	for _, note := range eventNotes(event.ID) {
+		lines = append(lines, fmt.Sprintf("Note: %s", note.Text))
+	}
+
+	if linked := details.Linked; linked != nil {
+		lines = append(lines, "", fmt.Sprintf("Linked %s: %s", linked.Kind, linked.Title))
+		if linked.State != "" {
+			state := linked.State
+			if linked.Merged {
+				state = "merged"
+			}
+			lines = append(lines, fmt.Sprintf("State: %s", state))
+		}
+		if linked.Author != "" {
+			lines = append(lines, fmt.Sprintf("Author: %s", linked.Author))
+		}
+		if linked.Kind != "issue" {
+			lines = append(lines, fmt.Sprintf("Changes: +%d -%d in %d files", linked.Additions, linked.Deletions, linked.ChangedFiles))
+		}
+		if linked.Kind != "commit" {
+			lines = append(lines, fmt.Sprintf("Comments: %d", linked.Comments))
+		}
+		if linked.Body != "" {
+			lines = append(lines, "")
+			lines = append(lines, strings.Split(strings.ReplaceAll(linked.Body, "\r\n", "\n"), "\n")...)
+		}
+	}
+
+	if len(event.Raw) > 0 {
+		var payload strings.Builder
+		lines = append(lines, "", "Payload:")
+		encoder := json.NewEncoder(&payload)
+		encoder.SetIndent("", "  ")
+		var raw interface{}
+		if json.Unmarshal(event.Raw, &raw) == nil && encoder.Encode(raw) == nil {
+			lines = append(lines, strings.Split(strings.TrimRight(payload.String(), "\n"), "\n")...)
+		}
+	}
+
+	return lines
+}
+
+// Look an event up by ID in every cached feed
+func findCachedEvent(id string) (Event, bool) {
+	for _, key := range cache.Keys() {
+		item, _ := cache.Peek(key)
+		for _, event := range cachedEvents(item) {
+			if event.ID == id {
+				return event, true
+			}
+		}
+	}
+
+	return Event{}, false
+}
+
+func runShow(args []string) {
+	flags := newFlagSet("show")
+	applyTimeouts := addTimeoutFlags(flags)
+	_ = flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) < 1 {
+		log.Fatalf("Usage: show [--timeout 10s] [--deadline 2m] [--retries 3] <event-id>")
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	applyTimeouts(newSettingsResolver(flags, config))
+
+	loadCache()
+	err = loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	event, found := findCachedEvent(args[0])
+	if !found {
+		log.Fatalf("Event %s is not in the cache, fetch the events that contain it first", args[0])
+	}
+
+	details, err := fetchEventDetails(event)
+	if err != nil {
+		exitIfInterrupted()
+		log.Fatalf("Error fetching event details: %v", err)
+	}
+
+	for _, line := range details.Lines() {
+		fmt.Println(line)
+	}
+}
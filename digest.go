@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A weekly summary of the work of a user or a team, grouped in sections
+type Digest struct {
+	Name    string
+	Members []string
+	From    string
+	To      string
+	Commits int
+	// Sections in the order they are shown, the empty ones are left out
+	Sections []DigestSection
+}
+
+type DigestSection struct {
+	Title string
+	// What the overview counts, e.g. "pull request" and " merged"
+	noun   string
+	suffix string
+	Items  []DigestItem
+}
+
+type DigestItem struct {
+	Date  string
+	Text  string
+	Title string
+	URL   string
+	// Set when the digest covers several members
+	Actor string
+}
+
+// The sections of a digest and the events each one lists
+var digestSections = []struct {
+	title   string
+	noun    string
+	suffix  string
+	matches func(event Event) bool
+}{
+	{"Commits", "commit", "", func(event Event) bool { return event.Action == ActionPushed }},
+	{"Pull requests opened", "pull request", " opened", func(event Event) bool {
+		return event.Action == ActionOpened && event.Target.Kind == TargetPullRequest
+	}},
+	{"Pull requests merged", "pull request", " merged", func(event Event) bool { return event.Action == ActionMerged }},
+	{"Issues", "issue", "", func(event Event) bool {
+		return event.Target.Kind == TargetIssue && (event.Action == ActionOpened || event.Action == ActionClosed || event.Action == ActionReopened)
+	}},
+	{"Reviews", "review", "", func(event Event) bool { return event.Action == ActionReviewed }},
+	{"Releases", "release", "", func(event Event) bool { return event.Action == ActionReleased }},
+}
+
+func buildDigest(name string, members []string, events []Event, from time.Time, to time.Time) Digest {
+	digest := Digest{
+		Name:    name,
+		Members: members,
+		From:    from.Local().Format(dayLayout),
+		// The end of the range is exclusive
+		To: to.Add(-time.Nanosecond).Local().Format(dayLayout),
+	}
+	for _, section := range digestSections {
+		current := DigestSection{Title: section.title, noun: section.noun, suffix: section.suffix}
+		// Oldest first, the digest reads as the story of the week
+		for i := len(events) - 1; i >= 0; i-- {
+			event := events[i]
+			if !section.matches(event) {
+				continue
+			}
+			item := DigestItem{
+				Date: event.CreatedAt.Local().Format(dayLayout),
+				Text: describeEvent(event),
+				URL:  eventHTMLURL(event),
+			}
+			if event.Target.Title != "" && event.Action != ActionForked && event.Action != ActionJoined {
+				item.Title = event.Target.Title
+			}
+			if len(members) > 1 {
+				item.Actor = event.Actor.Login
+			}
+			current.Items = append(current.Items, item)
+			if event.Action == ActionPushed {
+				digest.Commits += pushedCommits(event)
+			}
+		}
+		if len(current.Items) > 0 {
+			digest.Sections = append(digest.Sections, current)
+		}
+	}
+
+	return digest
+}
+
+func pushedCommits(event Event) int {
+	if event.Size < len(event.Commits) {
+		return len(event.Commits)
+	}
+	return event.Size
+}
+
+// The one line overview of the digest, e.g. "12 commits, 3 pull requests
+// merged"
+func (d Digest) Overview() string {
+	var parts []string
+	for _, section := range d.Sections {
+		count := len(section.Items)
+		if section.noun == "commit" {
+			count = d.Commits
+		}
+		parts = append(parts, fmt.Sprintf("%d %s%s", count, plural(count, section.noun), section.suffix))
+	}
+	if len(parts) == 0 {
+		return "No activity"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (d Digest) Heading() string {
+	return fmt.Sprintf("Digest of %s, %s to %s", d.Name, d.From, d.To)
+}
+
+func writeDigestMarkdown(w io.Writer, digest Digest) {
+	fmt.Fprintf(w, "# %s\n\n", markdownEscaper.Replace(digest.Heading()))
+	if len(digest.Members) > 1 {
+		fmt.Fprintf(w, "Members: %s\n\n", markdownEscaper.Replace(strings.Join(digest.Members, ", ")))
+	}
+	fmt.Fprintf(w, "%s\n", digest.Overview())
+	for _, section := range digest.Sections {
+		fmt.Fprintf(w, "\n## %s\n\n", section.Title)
+		for _, item := range section.Items {
+			line := fmt.Sprintf("- **%s** [%s](%s)", item.Date, markdownEscaper.Replace(item.Text), item.URL)
+			if item.Title != "" {
+				line += ": " + markdownEscaper.Replace(item.Title)
+			}
+			if item.Actor != "" {
+				line += " by " + markdownEscaper.Replace(item.Actor)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+var digestHTMLTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Heading}}</title>
+</head>
+<body>
+<h1>{{.Heading}}</h1>
+{{if gt (len .Members) 1}}<p>Members: {{range $i, $member := .Members}}{{if $i}}, {{end}}{{$member}}{{end}}</p>
+{{end}}<p>{{.Overview}}</p>
+{{range .Sections}}<h2>{{.Title}}</h2>
+<ul>
+{{range .Items}}<li><strong>{{.Date}}</strong> {{if .URL}}<a href="{{.URL}}">{{.Text}}</a>{{else}}{{.Text}}{{end}}{{if .Title}}: {{.Title}}{{end}}{{if .Actor}} by {{.Actor}}{{end}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+func writeDigestHTML(w io.Writer, digest Digest) error {
+	return digestHTMLTemplate.Execute(w, digest)
+}
+
+// digest <username or alias> writes the summary of the last week, or of
+// --since and --until, as Markdown or HTML, or posts it with --notify
+func runDigest(args []string) {
+	flags := newFlagSet("digest")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	applyProvider := addProviderFlags(flags)
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	applyDateRange := addDateRangeFlags(flags)
+	applyFilters := addFilterFlags(flags)
+	pages := flags.Int("pages", 3, "maximum number of pages to fetch per user")
+	format := flags.String("format", "markdown", "output format: markdown or html")
+	noProgress := flags.Bool("no-progress", false, "don't show the progress line on stderr")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	applyNotify := addNotifyFlag(flags)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Usage: digest [--provider name] [--token token] [--base-url url] [--preset name] [--since 7d] [--until 2024-01-31] [--format markdown|html] [--notify] [--timeout 10s] [--deadline 2m] [--retries 3] <username or alias>")
+	}
+	if *format != "markdown" && *format != "html" {
+		log.Fatalf("Unknown format %q, expected markdown or html", *format)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+	applyDateRange(&filter)
+	applyFilters(&filter)
+	// A week up to now unless the range says otherwise
+	if filter.Until.IsZero() {
+		filter.Until = time.Now()
+	}
+	if filter.Since.IsZero() {
+		filter.Since = filter.Until.AddDate(0, 0, -7)
+	}
+	settings := newSettingsResolver(flags, config)
+	providerConfig := ProviderConfig{Pages: *pages}
+	applyProvider(settings, &providerConfig)
+	applyTimeouts(settings)
+	_, err = newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+
+	applyCache(settings)
+	applyNotify(config)
+	loadCache()
+	cancelOnInterrupt()
+
+	name := flags.Arg(0)
+	members := uniqueStrings(expandUsername(config, name))
+	if !*noProgress {
+		fetchProgress.Start(len(members))
+	}
+	results := fetchUsersEvents(func() (Provider, error) {
+		return newProvider(*providerName, providerConfig)
+	}, members, 4)
+	fetchProgress.Stop()
+	saveCache()
+	exitIfInterrupted()
+
+	var events []Event
+	for _, result := range results {
+		if result.Err != nil {
+			exitIfInterrupted()
+			log.Fatalf("Error fetching events of %s: %v", result.Username, result.Err)
+		}
+		events = append(events, filter.Apply(result.Events)...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+	digest := buildDigest(name, members, events, filter.Since, filter.Until)
+
+	if len(notifiers) > 0 {
+		for _, notifier := range notifiers {
+			err = notifier.PostDigest(digest)
+			if err != nil {
+				log.Fatalf("Error posting the digest to %s: %v", notifier.Name, err)
+			}
+		}
+		return
+	}
+
+	if *format == "html" {
+		err = writeDigestHTML(os.Stdout, digest)
+		if err != nil {
+			log.Fatalf("Error writing digest: %v", err)
+		}
+		return
+	}
+	writeDigestMarkdown(os.Stdout, digest)
+}
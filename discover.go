@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+type DiscoveredRepository struct {
+	GithubRepository
+	RecentEvents int `json:"recent_events"`
+}
+
+// Maximum number of repository event feeds fetched at the same time
+const discoverWorkers = 5
+
+func searchRepositoriesByTopic(topic string, language string, limit int) ([]GithubRepository, error) {
+	query := fmt.Sprintf("topic:%s", topic)
+	if language != "" {
+		query += fmt.Sprintf(" language:%s", language)
+	}
+
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("sort", "updated")
+	params.Set("order", "desc")
+	params.Set("per_page", fmt.Sprintf("%d", limit))
+
+	githubUrl := "search/repositories?" + params.Encode()
+	var searchResponse GithubSearchRepositoriesResponse
+	err := getGithubJSON(githubUrl, &searchResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchResponse.Items, nil
+}
+
+// Count the events of a repository created after the given time, paging
+// until the events get older than that or the pages run out
+func countRecentRepositoryEvents(fullName string, after time.Time) (int, error) {
+	githubUrl := fmt.Sprintf("repos/%s/events?per_page=100", fullName)
+	count := 0
+	for page := 0; page < github.MaxEventPages && githubUrl != ""; page++ {
+		var events []github.Event
+		header, err := getGithubJSONWithToken(githubUrl, githubToken, &events)
+		if err != nil {
+			return count, err
+		}
+
+		// The events come newest first
+		for _, event := range events {
+			if !event.CreatedAt.After(after) {
+				return count, nil
+			}
+			count++
+		}
+		githubUrl = github.NextPageURL(header)
+	}
+
+	return count, nil
+}
+
+// Search repositories by topic and rank them by how many events they had in
+// the last days, falling back to stars when the activity is the same
+func discoverRepositories(topic string, language string, days int, limit int) ([]DiscoveredRepository, error) {
+	repositories, err := searchRepositoriesByTopic(topic, language, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	after := time.Now().AddDate(0, 0, -days)
+	discovered := make([]DiscoveredRepository, len(repositories))
+	errs := make([]error, len(repositories))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, discoverWorkers)
+	for i, repository := range repositories {
+		wg.Add(1)
+		go func(i int, repository GithubRepository) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			count, err := countRecentRepositoryEvents(repository.FullName, after)
+			discovered[i] = DiscoveredRepository{GithubRepository: repository, RecentEvents: count}
+			errs[i] = err
+		}(i, repository)
+	}
+	wg.Wait()
+
+	// A repository whose events failed is left out of the ranking
+	ranked := discovered[:0]
+	for i, err := range errs {
+		if err != nil {
+			logger.Warn("Counting repository events failed, skipping", "repo", repositories[i].FullName, "error", err)
+			continue
+		}
+		ranked = append(ranked, discovered[i])
+	}
+	discovered = ranked
+
+	sort.SliceStable(discovered, func(i, j int) bool {
+		if discovered[i].RecentEvents != discovered[j].RecentEvents {
+			return discovered[i].RecentEvents > discovered[j].RecentEvents
+		}
+		return discovered[i].StargazersCount > discovered[j].StargazersCount
+	})
+
+	return discovered, nil
+}
+
+func runDiscover(args []string) {
+	flags := newFlagSet("discover")
+	topic := flags.String("topic", "", "repository topic to search for")
+	language := flags.String("language", "", "only show repositories written in this language")
+	days := flags.Int("days", 30, "number of days of activity used for the ranking")
+	limit := flags.Int("limit", 20, "number of repositories to rank (max 100)")
+	format := flags.String("format", "text", "output format: text or json")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyToken := addGithubTokenFlag(flags)
+	_ = flags.Parse(args)
+	applyToken()
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	applyTimeouts(settings)
+
+	if *topic == "" {
+		log.Fatalf("The --topic flag is required")
+	}
+	if *limit < 1 || *limit > 100 {
+		log.Fatalf("--limit must be between 1 and 100")
+	}
+
+	repositories, err := discoverRepositories(*topic, *language, *days, *limit)
+	exitIfInterrupted()
+	if err != nil {
+		log.Fatalf("Error discovering repositories: %v", err)
+	}
+
+	switch *format {
+	case "json":
+		err = printJSON(repositories)
+		if err != nil {
+			log.Fatalf("Error encoding discovered repositories: %v", err)
+		}
+	case "text":
+		for _, repository := range repositories {
+			fmt.Printf("Repo Name: %s\n", repository.FullName)
+			fmt.Printf("Description: %s\n", repository.Description)
+			fmt.Printf("Language: %s\n", repository.Language)
+			fmt.Printf("Recent Events: %d\n", repository.RecentEvents)
+			fmt.Printf("Stars: %d\n", repository.StargazersCount)
+			fmt.Printf("Repo URL: %s\n", repository.HTMLURL)
+			fmt.Println("----------------------")
+		}
+	default:
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// Review states of a pull request, from the latest review of each reviewer
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes requested"
+	ReviewRequired         = "review required"
+)
+
+// The current state of the pull request or issue an event points to, set
+// with --enrich
+type TargetState struct {
+	// open, closed or merged
+	State  string   `json:"state"`
+	Draft  bool     `json:"draft,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+	// Pull requests only: approved, changes requested or review required
+	Review string `json:"review,omitempty"`
+}
+
+type githubItemStateResponse struct {
+	State  string `json:"state"`
+	Merged bool   `json:"merged"`
+	Draft  bool   `json:"draft"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	RequestedReviewers []json.RawMessage `json:"requested_reviewers"`
+}
+
+type githubReview struct {
+	State string `json:"state"`
+	User  struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// The state the reviews add up to: any reviewer still requesting changes
+// wins over the approvals
+func reviewStatus(reviews []githubReview, requested int) string {
+	latest := make(map[string]string)
+	for _, review := range reviews {
+		// Comments don't change what a reviewer decided before, a dismissal
+		// drops it
+		if review.State == "APPROVED" || review.State == "CHANGES_REQUESTED" || review.State == "DISMISSED" {
+			latest[review.User.Login] = review.State
+		}
+	}
+
+	status := ""
+	for _, state := range latest {
+		switch state {
+		case "CHANGES_REQUESTED":
+			return ReviewChangesRequested
+		case "APPROVED":
+			status = ReviewApproved
+		}
+	}
+	if status == "" && requested > 0 {
+		status = ReviewRequired
+	}
+
+	return status
+}
+
+// Fetch the state of a pull request or issue. It is cached like the events:
+// once expired it is asked again with its ETag, and a 304 that doesn't
+// count against the rate limit keeps it for another TTL.
+func fetchTargetState(repo string, kind string, number int) (TargetState, error) {
+	key := fmt.Sprintf("github-state-%s#%d", repo, number)
+	var item github.CacheItem
+	found := false
+	if !noCache {
+		item, found = cache.Get(key)
+	}
+	var state TargetState
+	if found && (!item.Expired() || offline) {
+		err := json.Unmarshal(item.Data, &state)
+		if err == nil {
+			return state, nil
+		}
+		found = false
+	}
+
+	path := fmt.Sprintf("repos/%s/issues/%d", repo, number)
+	if kind == TargetPullRequest {
+		path = fmt.Sprintf("repos/%s/pulls/%d", repo, number)
+	}
+	client := newGithubClient(githubToken)
+	req, err := client.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return state, err
+	}
+	if found && item.ETag != "" {
+		req.Header.Set("If-None-Match", item.ETag)
+	}
+
+	var response githubItemStateResponse
+	header, err := client.Do(req, &response)
+	if isNotModified(err) {
+		logger.Debug("Not modified, cache extended", "key", key)
+		item.ExpiresAt = time.Now().Add(cacheTTL)
+		cache.Set(key, item)
+		err = json.Unmarshal(item.Data, &state)
+		return state, err
+	}
+	if err != nil {
+		return state, err
+	}
+
+	state = TargetState{State: response.State, Draft: response.Draft}
+	if response.Merged {
+		state.State = "merged"
+	}
+	for _, label := range response.Labels {
+		state.Labels = append(state.Labels, label.Name)
+	}
+	if kind == TargetPullRequest {
+		var reviews []githubReview
+		err = getGithubJSON(path+"/reviews?per_page=100", &reviews)
+		if err != nil {
+			return state, err
+		}
+		state.Review = reviewStatus(reviews, len(response.RequestedReviewers))
+	}
+
+	if noCache {
+		return state, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return state, err
+	}
+	cache.Set(key, github.CacheItem{
+		Data:      data,
+		ExpiresAt: time.Now().Add(cacheTTL),
+		ETag:      header.Get("ETag"),
+	})
+
+	return state, nil
+}
+
+// Fill in the current state of the pull requests and issues of Github
+// events, the ones that can't be fetched are left without one
+func enrichEvents(events []Event) []Event {
+	enriched := make([]Event, 0, len(events))
+	for _, event := range events {
+		kind := event.Target.Kind
+		if isGithubEvent(event) && (kind == TargetPullRequest || kind == TargetIssue) && event.Target.Number > 0 {
+			state, err := fetchTargetState(event.Repo.Name, kind, event.Target.Number)
+			if err != nil {
+				logger.Warn("Fetching the state of an item failed", "repo", event.Repo.Name, "number", event.Target.Number, "error", err)
+			} else {
+				event.Target.State = &state
+			}
+		}
+		enriched = append(enriched, event)
+	}
+
+	return enriched
+}
+
+// Short state line, e.g. merged, approved, labels: bug, ui
+func formatTargetState(state TargetState) string {
+	parts := []string{state.State}
+	if state.Draft {
+		parts = append(parts, "draft")
+	}
+	if state.Review != "" {
+		parts = append(parts, state.Review)
+	}
+	if len(state.Labels) > 0 {
+		parts = append(parts, "labels: "+strings.Join(state.Labels, ", "))
+	}
+
+	return strings.Join(parts, ", ")
+}
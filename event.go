@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Action verbs shared by all providers
+const (
+	ActionPushed    = "pushed"
+	ActionCreated   = "created"
+	ActionDeleted   = "deleted"
+	ActionOpened    = "opened"
+	ActionClosed    = "closed"
+	ActionReopened  = "reopened"
+	ActionMerged    = "merged"
+	ActionCommented = "commented"
+	ActionReviewed  = "reviewed"
+	ActionStarred   = "starred"
+	ActionForked    = "forked"
+	ActionReleased  = "released"
+	ActionJoined    = "joined"
+	ActionOther     = "other"
+)
+
+// Kinds of object an event acts on
+const (
+	TargetBranch      = "branch"
+	TargetTag         = "tag"
+	TargetRepository  = "repository"
+	TargetIssue       = "issue"
+	TargetPullRequest = "pull_request"
+	TargetComment     = "comment"
+	TargetRelease     = "release"
+	TargetMember      = "member"
+	TargetUnknown     = "unknown"
+)
+
+type EventActor struct {
+	Login string `json:"login"`
+}
+
+type EventRepo struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type EventCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	// Empty until the signature is checked, then verified, unsigned or the
+	// reason the signature was rejected
+	Verification string `json:"verification,omitempty"`
+}
+
+type EventTarget struct {
+	Kind   string `json:"kind"`
+	Number int    `json:"number,omitempty"`
+	Title  string `json:"title,omitempty"`
+	URL    string `json:"url,omitempty"`
+	// Empty until fetched with --enrich, for pull requests and issues
+	State *TargetState `json:"state,omitempty"`
+}
+
+// Event is the provider-neutral model every provider normalizes into, so
+// formatters and stats only deal with this type. Type keeps the closest
+// Github event type name for display and filtering, and Raw preserves the
+// event exactly as the provider sent it.
+type Event struct {
+	ID       string        `json:"id"`
+	Provider string        `json:"provider,omitempty"`
+	Type     string        `json:"type"`
+	Action   string        `json:"action,omitempty"`
+	Target   EventTarget   `json:"target"`
+	Ref      string        `json:"ref,omitempty"`
+	Commits  []EventCommit `json:"commits,omitempty"`
+	// Number of commits of a push, providers may list fewer in Commits
+	Size      int             `json:"size,omitempty"`
+	Actor     EventActor      `json:"actor"`
+	Repo      EventRepo       `json:"repo"`
+	CreatedAt time.Time       `json:"created_at"`
+	Raw       json.RawMessage `json:"raw,omitempty"`
+}
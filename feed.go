@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// An account of a person on one of the providers
+type Account struct {
+	Provider string
+	Username string
+	BaseURL  string
+	// Token from the environment or the config file, empty for public access
+	Token string
+	// FeedOrg, FeedRepo or FeedReceived for the accounts given as
+	// github:org/name, github:repo/owner/name and github:received/username
+	Feed string
+}
+
+type FeedStats struct {
+	Total       int            `json:"total"`
+	PerProvider map[string]int `json:"per_provider"`
+	PerType     map[string]int `json:"per_type"`
+}
+
+// Parse an account written as provider:username[@base-url]
+func parseAccount(value string) (Account, error) {
+	var account Account
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return account, fmt.Errorf("invalid account %q, expected provider:username[@base-url]", value)
+	}
+
+	account.Provider = parts[0]
+	account.Username = parts[1]
+	for _, feed := range []string{FeedOrg, FeedRepo, FeedReceived} {
+		if strings.HasPrefix(account.Username, feed+"/") {
+			account.Feed = feed
+			account.Username = strings.TrimPrefix(account.Username, feed+"/")
+		}
+	}
+	if at := strings.Index(account.Username, "@"); at >= 0 {
+		account.BaseURL = account.Username[at+1:]
+		account.Username = account.Username[:at]
+		if !strings.Contains(account.BaseURL, "://") {
+			account.BaseURL = "https://" + account.BaseURL
+		}
+	}
+
+	return account, nil
+}
+
+// Fetch all accounts concurrently and merge their events into a single feed
+// ordered from newest to oldest. Events are deduplicated by provider and ID.
+func getMergedFeed(accounts []Account) ([]Event, error) {
+	feeds := make([][]Event, len(accounts))
+	errs := make([]error, len(accounts))
+
+	var wg sync.WaitGroup
+	for i, account := range accounts {
+		wg.Add(1)
+		go func(i int, account Account) {
+			defer wg.Done()
+
+			provider, err := newProvider(account.Provider, ProviderConfig{Token: account.Token, BaseURL: account.BaseURL, Feed: account.Feed})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			events, err := getEvents(provider, account.Username)
+			fetchProgress.UserDone()
+			if err != nil {
+				errs[i] = fmt.Errorf("%s:%s: %v", account.Provider, account.Username, err)
+				return
+			}
+
+			feeds[i] = events
+		}(i, account)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool)
+	var merged []Event
+	for _, feed := range feeds {
+		for _, event := range feed {
+			key := event.Provider + "/" + event.ID
+			if event.ID != "" && seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, event)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+
+	return merged, nil
+}
+
+func feedStats(events []Event) FeedStats {
+	stats := FeedStats{
+		Total:       len(events),
+		PerProvider: make(map[string]int),
+		PerType:     make(map[string]int),
+	}
+	for _, event := range events {
+		stats.PerProvider[event.Provider]++
+		stats.PerType[event.Type]++
+	}
+
+	return stats
+}
+
+// Print the counts of a map sorted by key
+func printCounts(title string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Println(title)
+	for _, key := range keys {
+		fmt.Printf("  %s: %d\n", key, counts[key])
+	}
+}
+
+func runFeed(args []string) {
+	flags := newFlagSet("feed")
+	var accountValues stringList
+	flags.Var(&accountValues, "account", "account as provider:username[@base-url], can be repeated (default feed.accounts)")
+	format := flags.String("format", "text", "output format: text or json")
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	var types stringList
+	flags.Var(&types, "type", "only show events of this type, e.g. PushEvent, can be repeated or comma-separated (replaces the preset types)")
+	applyDateRange := addDateRangeFlags(flags)
+	applyFilters := addFilterFlags(flags)
+	limit := flags.Int("limit", 0, "maximum number of events to print")
+	applyOrder := addOrderFlags(flags)
+	noProgress := flags.Bool("no-progress", false, "don't show the progress line on stderr")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	applyStore := addStoreFlags(flags, "none")
+	applyTimeDisplay := addTimeDisplayFlags(flags)
+	_ = flags.Parse(args)
+	order := applyOrder()
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+	if len(types) > 0 {
+		filter.Types = types
+	}
+	applyDateRange(&filter)
+	applyFilters(&filter)
+	filter.Limit = *limit
+
+	settings := newSettingsResolver(flags, config)
+	for _, resolveErr := range []error{
+		settings.Resolve("account", "", "feed.accounts"),
+	} {
+		if resolveErr != nil {
+			log.Fatalf("Error resolving settings: %v", resolveErr)
+		}
+	}
+	applyTimeouts(settings)
+	if len(accountValues) == 0 {
+		log.Fatalf("No accounts to merge, pass --account or set feed.accounts in %s", configPath())
+	}
+	applyCache(settings)
+	applyStore(settings)
+	applyTimeDisplay(settings)
+	accounts, err := parseAccounts(config, accountValues)
+	if err != nil {
+		log.Fatalf("Error parsing account: %v", err)
+	}
+
+	loadCache()
+	err = loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	cancelOnInterrupt()
+	if !*noProgress {
+		fetchProgress.Start(len(accounts))
+	}
+	events, err := getMergedFeed(accounts)
+	fetchProgress.Stop()
+	if err != nil {
+		exitIfInterrupted()
+		log.Fatalf("Error fetching events: %v", err)
+		return
+	}
+	events = filter.ApplyLimit(events)
+	order.sort(events)
+	stats := feedStats(events)
+
+	startPager()
+	defer stopPager()
+	switch *format {
+	case "json":
+		var output interface{} = events
+		if order.groupBy != "" {
+			output = order.group(events)
+		}
+		err = printJSON(struct {
+			Events interface{} `json:"events"`
+			Stats  FeedStats   `json:"stats"`
+		}{output, stats})
+		if err != nil {
+			log.Fatalf("Error encoding feed: %v", err)
+		}
+	case "text":
+		renderer := newEventRenderer(os.Stdout)
+		order.print(os.Stdout, renderer, events, func(event Event) {
+			renderer.Field("Provider", event.Provider)
+			renderer.Event(event)
+			renderer.Separator()
+		})
+		fmt.Printf("Total Events: %d\n", stats.Total)
+		printCounts("Events per provider:", stats.PerProvider)
+		printCounts("Events per type:", stats.PerType)
+		renderer.DayNotes(events)
+	default:
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+
+	// Save the cache before exiting
+	saveCache()
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// fetch prints the recent events of users, it is also what runs when the
+// first argument is a username or a flag
+func runFetch(args []string) {
+	runFetchCommand("fetch", args)
+}
+
+// watch is fetch in follow mode
+func runWatch(args []string) {
+	runFetchCommand("watch", append([]string{"--follow"}, args...))
+}
+
+// org is fetch for the events of Github organizations
+func runOrg(args []string) {
+	runFetchCommand("org", append([]string{"--org"}, args...))
+}
+
+// repo is fetch for the events of Github repositories
+func runRepo(args []string) {
+	runFetchCommand("repo", append([]string{"--repository"}, args...))
+}
+
+// Run fetch with the usage of the named command
+func runFetchCommand(name string, args []string) {
+	flags := newFlagSet(name)
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	token := flags.String("token", "", "access token for the provider (defaults to GITHUB_TOKEN, GITLAB_TOKEN, GITEA_TOKEN or BITBUCKET_TOKEN)")
+	baseURL := flags.String("base-url", "", "base URL of a self-hosted provider instance")
+	var follow bool
+	flags.BoolVar(&follow, "f", false, "keep running and print new events as they arrive")
+	flags.BoolVar(&follow, "follow", false, "same as -f")
+	flags.BoolVar(&follow, "watch", false, "same as -f")
+	interval := flags.Duration("interval", time.Minute, "polling interval used by -f, longer when the provider asks for it (X-Poll-Interval)")
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	var types stringList
+	flags.Var(&types, "type", "only show events of this type, e.g. PushEvent, can be repeated or comma-separated (replaces the preset types)")
+	applyDateRange := addDateRangeFlags(flags)
+	applyFilters := addFilterFlags(flags)
+	format := flags.String("format", "text", "output format: text, json, ndjson, csv, markdown, html or ics")
+	pretty := flags.Bool("pretty", false, "indent the json output")
+	pages := flags.Int("pages", 0, "maximum number of pages to fetch, events are printed as each page arrives (default 1 for github)")
+	limit := flags.Int("limit", 0, "maximum number of events per user, pages are fetched until it is reached (Github serves up to 300)")
+	stdin := flags.Bool("stdin", false, "read usernames from stdin, one per line, and process each as it arrives")
+	noProgress := flags.Bool("no-progress", false, "don't show the progress line on stderr")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	applyStore := addStoreFlags(flags, "none")
+	applyTimeDisplay := addTimeDisplayFlags(flags)
+	org := flags.Bool("org", false, "the names are organizations, fetch their public events")
+	repository := flags.Bool("repository", false, "the names are repositories as owner/name, or Gitlab projects, fetch their events")
+	received := flags.Bool("received", false, "fetch the events users receive from who and what they follow and watch, instead of their own")
+	merge := flags.Bool("merge", false, "with several users, print their events merged newest first instead of grouped by user")
+	applyOrder := addOrderFlags(flags)
+	concurrency := flags.Int("concurrency", 4, "number of users fetched at the same time")
+	verifyCommits := flags.Bool("verify-commits", false, "show whether the pushed commits are signed and verified")
+	onlyUnverified := flags.Bool("only-unverified", false, "only show pushes with unsigned or unverified commits")
+	enrich := flags.Bool("enrich", false, "show the current state of pull requests and issues: merged or closed, labels and review status")
+	applyNotify := addNotifyFlag(flags)
+	flags.BoolVar(&desktopNotifications, "notify-desktop", false, "show a desktop notification for the new events of follow mode")
+	flags.BoolVar(&newOnly, "new-only", false, "only print the events that weren't cached by the last run, exit with status 3 when there are none")
+	templateValue := flags.String("template", "", "print each event with a Go text/template, or the path of a file holding one, e.g. '{{.CreatedAt.Format \"2006-01-02\"}} {{.Repo.Name}} {{.Sentence}}'")
+	open := flags.Int("open", 0, "open the page of the n-th event listed, its pull request, issue or commit when it has one, in the browser instead of printing")
+	explainConfig := flags.Bool("explain-config", false, "print the effective value and the source of every setting, then exit")
+	_ = flags.Parse(args)
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	// Flags > env > config file > defaults, the provider is resolved first as
+	// the token and base URL settings depend on it
+	settings := newSettingsResolver(flags, config)
+	err = settings.Resolve("provider", "GITHUB_ACTIVITY_PROVIDER", "defaults.provider")
+	if err != nil {
+		log.Fatalf("Error resolving settings: %v", err)
+	}
+	envPrefix := providerEnvPrefix(*providerName)
+	for _, resolveErr := range []error{
+		settings.ResolveSecret("token", envPrefix+"_TOKEN", *providerName+".token"),
+		settings.Resolve("base-url", envPrefix+"_BASE_URL", *providerName+".base_url"),
+		settings.Resolve("api-url", "GITHUB_API_URL", "github.api_url"),
+		settings.Resolve("format", "GITHUB_ACTIVITY_FORMAT", "defaults.format"),
+		settings.Resolve("pages", "GITHUB_ACTIVITY_PAGES", "defaults.pages"),
+		settings.Resolve("limit", "GITHUB_ACTIVITY_LIMIT", "defaults.limit"),
+		settings.Resolve("preset", "GITHUB_ACTIVITY_PRESET", "defaults.preset"),
+		settings.Resolve("interval", "GITHUB_ACTIVITY_INTERVAL", "defaults.interval"),
+	} {
+		if resolveErr != nil {
+			log.Fatalf("Error resolving settings: %v", resolveErr)
+		}
+	}
+	applyTimeouts(settings)
+	applyCache(settings)
+	applyStore(settings)
+	applyTimeDisplay(settings)
+	if *explainConfig {
+		settings.Explain()
+		return
+	}
+
+	switch *format {
+	case "text", "json", "ndjson", "csv", "markdown", "html", "ics":
+	default:
+		log.Fatalf("Unknown format %q, expected text, json, ndjson, csv, markdown, html or ics", *format)
+	}
+	if *templateValue != "" {
+		if *format != "text" {
+			log.Fatalf("--template replaces the text output, it can't be combined with --format %s", *format)
+		}
+		eventTemplate, err = parseEventTemplate(*templateValue)
+		if err != nil {
+			log.Fatalf("Error parsing template: %v", err)
+		}
+	}
+	names := flags.Args()
+	if len(names) == 0 && !*stdin {
+		username := os.Getenv("GITHUB_ACTIVITY_USERNAME")
+		if username == "" {
+			username = config.String("defaults.username", "")
+		}
+		if username == "" {
+			log.Fatalf("Missing username, pass one or set defaults.username in %s", configPath())
+		}
+		names = []string{username}
+	}
+	if *stdin && *format != "text" && *format != "ndjson" {
+		log.Fatalf("--stdin streams its output, use --format ndjson")
+	}
+	if follow && (*stdin || *format != "text") {
+		log.Fatalf("Follow mode takes a single user and prints text")
+	}
+	applyNotify(config)
+	if len(notifiers) > 0 && !follow {
+		log.Fatalf("--notify posts the new events of follow mode, add -f")
+	}
+	if desktopNotifications {
+		if !follow {
+			log.Fatalf("--notify-desktop shows the new events of follow mode, add -f")
+		}
+		err = checkDesktopNotifications()
+		if err != nil {
+			log.Fatalf("Error enabling desktop notifications: %v", err)
+		}
+	}
+	if newOnly && (follow || *stdin) {
+		log.Fatalf("--new-only takes usernames, follow mode already prints only the new events")
+	}
+	if newOnly && (noCache || offline) {
+		log.Fatalf("--new-only compares the fetched events to the cached ones, it can't be combined with --no-cache or --offline")
+	}
+	if offline && follow {
+		log.Fatalf("Follow mode polls for new events, it can't be combined with --offline")
+	}
+
+	if *open < 0 {
+		log.Fatalf("--open takes the position of an event, starting at 1")
+	}
+	if *open > 0 && (follow || *stdin || newOnly) {
+		log.Fatalf("--open picks an event of the list, it can't be combined with -f, --stdin or --new-only")
+	}
+
+	if *concurrency < 1 {
+		log.Fatalf("--concurrency must be at least 1")
+	}
+	order := applyOrder()
+	if order.groupBy != "" && *format != "text" && *format != "json" {
+		log.Fatalf("--group-by prints headers, it works with --format text or json")
+	}
+	if !order.streams() && (follow || *stdin) {
+		log.Fatalf("--group-by and --sort asc need every event, they can't be combined with -f or --stdin")
+	}
+
+	feed := FeedUser
+	feeds := 0
+	for _, option := range []struct {
+		set  bool
+		feed string
+	}{{*org, FeedOrg}, {*repository, FeedRepo}, {*received, FeedReceived}} {
+		if option.set {
+			feed = option.feed
+			feeds++
+		}
+	}
+	if feeds > 1 {
+		log.Fatalf("--org, --repository and --received can't be combined")
+	}
+
+	// Providers reject the feeds their forge doesn't have
+	providerConfig := ProviderConfig{Token: *token, BaseURL: *baseURL, Pages: *pages, Limit: *limit, Feed: feed}
+	provider, err := newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+	if len(types) > 0 {
+		filter.Types = types
+	}
+	applyDateRange(&filter)
+	applyFilters(&filter)
+	filter.VerifyCommits = *verifyCommits
+	filter.OnlyUnverified = *onlyUnverified
+	filter.Enrich = *enrich
+	filter.Limit = *limit
+
+	loadCache()
+	err = loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+	cancelOnInterrupt()
+
+	if *stdin {
+		if !*noProgress {
+			fetchProgress.Start(0)
+		}
+		runBatch(os.Stdin, provider, config, filter, *format)
+		fetchProgress.Stop()
+		saveCache()
+		return
+	}
+
+	usernames := uniqueStrings(expandUsernames(config, names))
+	if *providerName == "github" {
+		if *token != "" {
+			githubToken = *token
+		}
+		// Only users have private events of their own to warn about
+		if feed == FeedUser {
+			preflightOwnEvents(*token, usernames)
+		}
+	}
+	if *open > 0 {
+		if len(usernames) != 1 {
+			log.Fatalf("--open takes a single user, got %s", strings.Join(usernames, ", "))
+		}
+		openEvent(provider, usernames[0], filter, *open)
+		return
+	}
+	if follow {
+		if len(usernames) != 1 {
+			log.Fatalf("Follow mode takes a single user, got %s", strings.Join(usernames, ", "))
+		}
+		followEvents(provider, usernames[0], *interval, filter)
+		return
+	}
+
+	// The progress line would be drawn over the pager
+	if !startPager() && !*noProgress {
+		fetchProgress.Start(len(usernames))
+	}
+	defer stopPager()
+
+	// A single user streams its events as the pages arrive
+	if len(usernames) == 1 && order.streams() && (*format == "text" || *format == "ndjson" || *format == "csv") {
+		printed, err := printUserEvents(provider, usernames[0], filter, *format, false)
+		fetchProgress.Stop()
+		if err != nil {
+			stopPager()
+			exitIfInterrupted()
+			log.Fatalf("Error fetching events of %s: %v", usernames[0], err)
+		}
+		logRateLimit(provider.RateLimitInfo())
+		saveCache()
+		if newOnly && printed == 0 {
+			stopPager()
+			os.Exit(exitNothingNew)
+		}
+		return
+	}
+
+	// Without --merge the ndjson lines of a user are printed as soon as its
+	// events are fetched, instead of after every user
+	var onDone func(result UserEvents)
+	if *format == "ndjson" && !*merge {
+		onDone = func(result UserEvents) {
+			fetchProgress.Clear()
+			printUserResultLines(result, filter, order)
+		}
+	}
+
+	// Every worker gets its own provider, providers keep the state of their
+	// last request
+	results := fetchUsersEventsAsDone(func() (Provider, error) {
+		return newProvider(*providerName, providerConfig)
+	}, usernames, *concurrency, onDone)
+	fetchProgress.Stop()
+
+	failed := false
+	if onDone == nil {
+		failed = printUsersEvents(results, filter, *format, *merge, *pretty, order)
+	} else {
+		for _, result := range results {
+			failed = failed || result.Err != nil
+		}
+	}
+	logRateLimit(lowestRateLimit(results))
+
+	// Save the cache before exiting
+	saveCache()
+	stopPager()
+	exitIfInterrupted()
+	if failed {
+		os.Exit(1)
+	}
+	if newOnly && !anyEvents(results) {
+		os.Exit(exitNothingNew)
+	}
+}
+
+// Open the page of the n-th event of a user, counted from 1 like the list
+// fetch prints
+func openEvent(provider Provider, username string, filter EventFilter, n int) {
+	events, err := getEvents(provider, username)
+	if err != nil {
+		exitIfInterrupted()
+		log.Fatalf("Error fetching events of %s: %v", username, err)
+	}
+	saveCache()
+
+	events = filter.ApplyLimit(events)
+	if n > len(events) {
+		log.Fatalf("--open %d: %s has %d events", n, username, len(events))
+	}
+	pageURL := eventHTMLURL(events[n-1])
+	if pageURL == "" {
+		log.Fatalf("Event %s has no page to open", events[n-1].ID)
+	}
+
+	fmt.Println(pageURL)
+	err = openBrowser(pageURL)
+	if err != nil {
+		log.Fatalf("Error opening browser: %v", err)
+	}
+}
+
+// Exit status of --new-only when no event is new, a cron job can tell it from
+// a failure
+const exitNothingNew = 3
+
+func anyEvents(results []UserEvents) bool {
+	for _, result := range results {
+		if len(result.Events) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Log the quota left after the last request with --verbose, nothing when
+// every event came from the cache
+func logRateLimit(rateLimit RateLimit) {
+	if rateLimit.Limit == 0 {
+		return
+	}
+
+	logger.Info("Rate limit", "remaining", rateLimit.Remaining, "limit", rateLimit.Limit, "reset", rateLimit.Reset)
+}
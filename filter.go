@@ -0,0 +1,250 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Which events to show and how, built from a preset
+type EventFilter struct {
+	// Repository glob patterns, empty means every repository
+	Repos []string
+	// Event types, empty means every type
+	Types []string
+	// Timezone the timestamps are shown in, nil keeps them as received
+	Location *time.Location
+	// Check the signatures of pushed commits, and keep only the pushes with
+	// an unsigned or unverified commit
+	VerifyCommits  bool
+	OnlyUnverified bool
+	// Fetch the current state of the pull requests and issues of the events
+	Enrich bool
+	// At most this many events are shown per user, zero shows them all
+	Limit int
+	// Only events created in this range, zero times leave it open
+	Since time.Time
+	Until time.Time
+	// Events left out: of bot accounts, whose login ends with [bot], of these
+	// types and of the repositories matching these glob patterns
+	ExcludeBots  bool
+	ExcludeTypes []string
+	ExcludeRepos []string
+}
+
+// Build the filter of the named preset, an empty name is a filter that
+// keeps everything
+func filterFromPreset(config Config, name string) (EventFilter, error) {
+	if name == "" {
+		return EventFilter{}, nil
+	}
+
+	return presetFilter(config, name)
+}
+
+// Build the filter of a preset defined in the config file as
+//
+//	[presets.work]
+//	repos = ["mycorp/*"]
+//	types = ["PushEvent", "PullRequestEvent"]
+//	timezone = "Asia/Jakarta"
+//	exclude_bots = true
+//	exclude_types = ["WatchEvent"]
+//	exclude_repos = ["mycorp/sandbox-*"]
+func presetFilter(config Config, name string) (EventFilter, error) {
+	prefix := "presets." + name + "."
+	found := false
+	for key := range config {
+		if strings.HasPrefix(key, prefix) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return EventFilter{}, fmt.Errorf("unknown preset %q, defined presets: %s", name, strings.Join(presetNames(config), ", "))
+	}
+
+	filter := EventFilter{
+		Repos:        config.Strings(prefix + "repos"),
+		Types:        config.Strings(prefix + "types"),
+		ExcludeBots:  config.Bool(prefix+"exclude_bots", false),
+		ExcludeTypes: config.Strings(prefix + "exclude_types"),
+		ExcludeRepos: config.Strings(prefix + "exclude_repos"),
+	}
+	for _, patterns := range [][]string{filter.Repos, filter.ExcludeRepos} {
+		if err := validateRepoPatterns(patterns); err != nil {
+			return filter, fmt.Errorf("preset %s: %v", name, err)
+		}
+	}
+	if timezone := config.String(prefix+"timezone", ""); timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return filter, fmt.Errorf("preset %s: %v", name, err)
+		}
+		filter.Location = location
+	}
+
+	return filter, nil
+}
+
+func presetNames(config Config) []string {
+	return configSectionNames(config, "presets")
+}
+
+// Register --since and --until, the returned function sets the parsed range
+// on a filter once the flags are parsed
+func addDateRangeFlags(flags *flag.FlagSet) func(filter *EventFilter) {
+	since := flags.String("since", "", "only events after a date like 2024-01-01 or a duration ago like 7d")
+	until := flags.String("until", "", "only events before a date like 2024-01-31 (included) or a duration ago like 1d")
+
+	return func(filter *EventFilter) {
+		var err error
+		if *since != "" {
+			filter.Since, err = parseTimeBound(*since, false)
+			if err != nil {
+				log.Fatalf("Error parsing --since: %v", err)
+			}
+		}
+		if *until != "" {
+			filter.Until, err = parseTimeBound(*until, true)
+			if err != nil {
+				log.Fatalf("Error parsing --until: %v", err)
+			}
+		}
+	}
+}
+
+// Register --include-repo (or --repo), --exclude-bots, --exclude-type and
+// --exclude-repo, the returned function sets them on a filter once the flags
+// are parsed: --include-repo replaces the repositories of the preset, the
+// exclusions are added to its own
+func addFilterFlags(flags *flag.FlagSet) func(filter *EventFilter) {
+	var includeRepos stringList
+	flags.Var(&includeRepos, "include-repo", "only show the events of repositories matching this glob, e.g. myorg/* or */infra-*, can be repeated (replaces the preset repos)")
+	flags.Var(&includeRepos, "repo", "same as --include-repo")
+	excludeBots := flags.Bool("exclude-bots", false, "leave out the events of bot accounts, whose login ends with [bot]")
+	var excludeTypes, excludeRepos stringList
+	flags.Var(&excludeTypes, "exclude-type", "leave out the events of this type, e.g. WatchEvent, can be repeated or comma-separated")
+	flags.Var(&excludeRepos, "exclude-repo", "leave out the events of repositories matching this glob, e.g. mycorp/sandbox-*, can be repeated")
+
+	return func(filter *EventFilter) {
+		if err := validateRepoPatterns(includeRepos); err != nil {
+			log.Fatalf("Error parsing --include-repo: %v", err)
+		}
+		if err := validateRepoPatterns(excludeRepos); err != nil {
+			log.Fatalf("Error parsing --exclude-repo: %v", err)
+		}
+		if len(includeRepos) > 0 {
+			filter.Repos = includeRepos
+		}
+		if *excludeBots {
+			filter.ExcludeBots = true
+		}
+		filter.ExcludeTypes = append(filter.ExcludeTypes, excludeTypes...)
+		filter.ExcludeRepos = append(filter.ExcludeRepos, excludeRepos...)
+	}
+}
+
+func isBot(login string) bool {
+	return strings.HasSuffix(login, "[bot]")
+}
+
+// Parse a date, a timestamp or a duration before now like 7d. A date ends
+// the range at the end of the day when endOfDay is set, so it is included.
+func parseTimeBound(value string, endOfDay bool) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return date, nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return timestamp, nil
+	}
+	duration, err := parseRetention(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected a date like 2024-01-01 or a duration like 7d", value)
+	}
+
+	return time.Now().Add(-duration), nil
+}
+
+func (f EventFilter) Matches(event Event) bool {
+	if !f.Since.IsZero() && event.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !event.CreatedAt.Before(f.Until) {
+		return false
+	}
+
+	if f.OnlyUnverified && !hasUnverifiedCommit(event) {
+		return false
+	}
+
+	if f.ExcludeBots && isBot(event.Actor.Login) {
+		return false
+	}
+	for _, eventType := range f.ExcludeTypes {
+		if event.Type == eventType {
+			return false
+		}
+	}
+	if matchesRepo(f.ExcludeRepos, event.Repo.Name) {
+		return false
+	}
+
+	if len(f.Types) > 0 {
+		matched := false
+		for _, eventType := range f.Types {
+			if event.Type == eventType {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(f.Repos) > 0 && !matchesRepo(f.Repos, event.Repo.Name) {
+		return false
+	}
+
+	return true
+}
+
+// Like Apply, keeping at most Limit events
+func (f EventFilter) ApplyLimit(events []Event) []Event {
+	events = f.Apply(events)
+	if f.Limit > 0 && len(events) > f.Limit {
+		events = events[:f.Limit]
+	}
+
+	return events
+}
+
+// Keep the matching events, with their timestamps in the filter timezone
+func (f EventFilter) Apply(events []Event) []Event {
+	if f.VerifyCommits || f.OnlyUnverified {
+		events = verifyEventCommits(events)
+	}
+
+	filtered := make([]Event, 0, len(events))
+	for _, event := range events {
+		if !f.Matches(event) {
+			continue
+		}
+		if f.Location != nil {
+			event.CreatedAt = event.CreatedAt.In(f.Location)
+		}
+		filtered = append(filtered, event)
+	}
+	// Only the matching events are worth the requests
+	if f.Enrich {
+		filtered = enrichEvents(filtered)
+	}
+
+	return filtered
+}
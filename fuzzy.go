@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Match pattern as a case-insensitive subsequence of text. It returns the
+// rune positions of the matched characters and a score that rewards
+// consecutive matches and matches at word starts.
+func fuzzyMatch(pattern string, text string) ([]int, int, bool) {
+	patternRunes := []rune(strings.ToLower(pattern))
+	if len(patternRunes) == 0 {
+		return nil, 0, true
+	}
+
+	textRunes := []rune(text)
+	var positions []int
+	score := 0
+	next := 0
+	for i, r := range textRunes {
+		if unicode.ToLower(r) != patternRunes[next] {
+			continue
+		}
+
+		score++
+		if len(positions) > 0 && positions[len(positions)-1] == i-1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(textRunes[i-1]) && !unicode.IsDigit(textRunes[i-1]) {
+			score++
+		}
+		positions = append(positions, i)
+
+		next++
+		if next == len(patternRunes) {
+			return positions, score, true
+		}
+	}
+
+	return nil, 0, false
+}
+
+// The text the dashboard search looks at: the event line plus the commit
+// messages of pushes
+func eventSearchText(event Event) string {
+	text := formatEventLine(event)
+	for _, commit := range event.Commits {
+		text += "  " + strings.SplitN(commit.Message, "\n", 2)[0]
+	}
+
+	return text
+}
+
+// Fit text to width and highlight the runes at the matched positions
+func highlightMatches(text string, positions []int, width int) string {
+	fitted := []rune(fitWidth(text, width))
+	matched := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		matched[position] = true
+	}
+
+	var line strings.Builder
+	for i, r := range fitted {
+		if matched[i] && (i < len(fitted)-1 || r != '…') {
+			line.WriteString("\x1b[1;33m" + string(r) + "\x1b[0m")
+			continue
+		}
+		line.WriteRune(r)
+	}
+
+	return line.String()
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// Codeberg is the largest public Forgejo instance, so it is used when no
+// base URL is given
+const defaultGiteaBaseURL = "https://codeberg.org"
+
+// Activities per page when the API isn't asked for more, and the most
+// instances allow
+const (
+	giteaDefaultPerPage = 30
+	giteaMaxPerPage     = 50
+)
+
+type GiteaActivity struct {
+	ID      int64     `json:"id"`
+	OpType  string    `json:"op_type"`
+	RefName string    `json:"ref_name"`
+	Content string    `json:"content"`
+	Created time.Time `json:"created"`
+	ActUser struct {
+		Login string `json:"login"`
+	} `json:"act_user"`
+	Repo struct {
+		FullName string `json:"full_name"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repo"`
+}
+
+// Gitea and Forgejo share the same activities API
+type giteaProvider struct {
+	token     string
+	baseURL   string
+	maxPages  int
+	limit     int
+	rateLimit RateLimit
+	// ID of the newest stored event, paging stops at it
+	stopAt string
+	// FeedOrg or FeedRepo when not the activities of users
+	feed string
+}
+
+func init() {
+	factory := func(config ProviderConfig) (Provider, error) {
+		if config.Feed == FeedReceived {
+			return nil, fmt.Errorf("gitea has no received events, only the activities of users, organizations and repositories")
+		}
+
+		provider := newGiteaProvider(config.Token, config.BaseURL)
+		provider.maxPages = config.Pages
+		if provider.maxPages <= 0 {
+			provider.maxPages = 1
+			// A limit alone fetches as many pages as it takes
+			if config.Limit > 0 {
+				provider.maxPages = github.MaxEventPages
+			}
+		}
+		provider.limit = config.Limit
+		provider.feed = config.Feed
+		provider.stopAt = config.StopAt
+		return provider, nil
+	}
+	RegisterProvider("gitea", factory)
+	RegisterProvider("forgejo", factory)
+}
+
+func newGiteaProvider(token string, baseURL string) *giteaProvider {
+	if baseURL == "" {
+		baseURL = defaultGiteaBaseURL
+	}
+	if token == "" {
+		token = defaultProviderToken("gitea", baseURL)
+	}
+
+	return &giteaProvider{
+		token:    token,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		maxPages: 1,
+	}
+}
+
+func (p *giteaProvider) Name() string {
+	return "gitea@" + hostOf(p.baseURL)
+}
+
+func (p *giteaProvider) FetchEvents(username string) ([]json.RawMessage, error) {
+	var activities []json.RawMessage
+	err := p.FetchEventPages(username, func(page []json.RawMessage) {
+		activities = append(activities, page...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+// Follow the Link header up to maxPages, stopping early once limit
+// activities were fetched
+func (p *giteaProvider) FetchEventPages(name string, onPage func([]json.RawMessage)) error {
+	feedPath := "users/" + url.PathEscape(name)
+	switch p.feed {
+	case FeedOrg:
+		feedPath = "orgs/" + url.PathEscape(name)
+	case FeedRepo:
+		// owner/name stays a path
+		feedPath = "repos/" + name
+	}
+	perPage := giteaDefaultPerPage
+	// Bigger pages take fewer requests when more than the default are wanted
+	if p.limit > giteaDefaultPerPage {
+		perPage = giteaMaxPerPage
+	}
+	giteaUrl := fmt.Sprintf("%s/api/v1/%s/activities/feeds?limit=%d", p.baseURL, feedPath, perPage)
+
+	fetched := 0
+	for page := 0; page < p.maxPages && giteaUrl != ""; page++ {
+		req, err := http.NewRequestWithContext(interrupted, http.MethodGet, giteaUrl, nil)
+		if err != nil {
+			return err
+		}
+		if p.token != "" {
+			req.Header.Set("Authorization", "token "+p.token)
+		}
+
+		var activities []json.RawMessage
+		header, err := doJSONRequest(req, &activities)
+		p.rateLimit = github.ParseRateLimit(header, "X-RateLimit-")
+		if err != nil {
+			return err
+		}
+		if p.limit > 0 && fetched+len(activities) > p.limit {
+			activities = activities[:p.limit-fetched]
+		}
+		activities, known := eventsBefore(activities, p.stopAt)
+		fetched += len(activities)
+		onPage(activities)
+
+		if known || p.limit > 0 && fetched >= p.limit {
+			break
+		}
+		giteaUrl = github.NextPageURL(header)
+	}
+
+	return nil
+}
+
+func (p *giteaProvider) Normalize(raw json.RawMessage) (Event, error) {
+	var activity GiteaActivity
+	err := json.Unmarshal(raw, &activity)
+	if err != nil {
+		return Event{}, err
+	}
+
+	action, kind := giteaActionAndKind(activity.OpType)
+	event := Event{
+		ID:        strconv.FormatInt(activity.ID, 10),
+		Provider:  p.Name(),
+		Type:      giteaEventType(activity.OpType),
+		Action:    action,
+		Target:    EventTarget{Kind: kind},
+		Ref:       strings.TrimPrefix(strings.TrimPrefix(activity.RefName, "refs/heads/"), "refs/tags/"),
+		Actor:     EventActor{Login: activity.ActUser.Login},
+		Repo:      EventRepo{Name: activity.Repo.FullName, URL: activity.Repo.HTMLURL},
+		CreatedAt: activity.Created,
+		Raw:       raw,
+	}
+
+	// Pushes carry the commits as JSON in the content
+	if activity.OpType == "commit_repo" || activity.OpType == "mirror_sync_push" {
+		var pushCommits struct {
+			Commits []struct {
+				Sha1    string
+				Message string
+			}
+		}
+		if json.Unmarshal([]byte(activity.Content), &pushCommits) == nil {
+			for _, commit := range pushCommits.Commits {
+				event.Commits = append(event.Commits, EventCommit{SHA: commit.Sha1, Message: commit.Message})
+			}
+		}
+	}
+
+	// Issue and pull request activities carry "number|title" as content
+	if kind == TargetIssue || kind == TargetPullRequest {
+		parts := strings.SplitN(activity.Content, "|", 2)
+		event.Target.Number, _ = strconv.Atoi(parts[0])
+		if len(parts) == 2 {
+			event.Target.Title = parts[1]
+		}
+	}
+
+	return event, nil
+}
+
+func (p *giteaProvider) RateLimitInfo() RateLimit {
+	return p.rateLimit
+}
+
+// Translate a Gitea operation type into the closest Github event type
+func giteaEventType(opType string) string {
+	switch opType {
+	case "commit_repo", "mirror_sync_push":
+		return "PushEvent"
+	case "create_repo", "push_tag", "mirror_sync_create":
+		return "CreateEvent"
+	case "delete_tag", "delete_branch", "mirror_sync_delete":
+		return "DeleteEvent"
+	case "create_issue", "close_issue", "reopen_issue":
+		return "IssuesEvent"
+	case "create_pull_request", "merge_pull_request", "close_pull_request", "reopen_pull_request", "auto_merge_pull_request":
+		return "PullRequestEvent"
+	case "approve_pull_request", "reject_pull_request":
+		return "PullRequestReviewEvent"
+	case "comment_issue", "comment_pull":
+		return "IssueCommentEvent"
+	case "star_repo":
+		return "WatchEvent"
+	case "fork_repo":
+		return "ForkEvent"
+	case "publish_release":
+		return "ReleaseEvent"
+	default:
+		return "GiteaEvent"
+	}
+}
+
+func giteaActionAndKind(opType string) (string, string) {
+	switch opType {
+	case "commit_repo", "mirror_sync_push":
+		return ActionPushed, TargetBranch
+	case "create_repo":
+		return ActionCreated, TargetRepository
+	case "push_tag", "mirror_sync_create":
+		return ActionCreated, TargetTag
+	case "delete_tag":
+		return ActionDeleted, TargetTag
+	case "delete_branch", "mirror_sync_delete":
+		return ActionDeleted, TargetBranch
+	case "create_issue":
+		return ActionOpened, TargetIssue
+	case "close_issue":
+		return ActionClosed, TargetIssue
+	case "reopen_issue":
+		return ActionReopened, TargetIssue
+	case "comment_issue":
+		return ActionCommented, TargetIssue
+	case "create_pull_request":
+		return ActionOpened, TargetPullRequest
+	case "merge_pull_request", "auto_merge_pull_request":
+		return ActionMerged, TargetPullRequest
+	case "close_pull_request":
+		return ActionClosed, TargetPullRequest
+	case "reopen_pull_request":
+		return ActionReopened, TargetPullRequest
+	case "comment_pull":
+		return ActionCommented, TargetPullRequest
+	case "approve_pull_request", "reject_pull_request":
+		return ActionReviewed, TargetPullRequest
+	case "star_repo":
+		return ActionStarred, TargetRepository
+	case "fork_repo":
+		return ActionForked, TargetRepository
+	case "publish_release":
+		return ActionReleased, TargetRelease
+	default:
+		return ActionOther, TargetUnknown
+	}
+}
+
+// Strip the scheme from a base URL so it can be used in provider names
+func hostOf(baseURL string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(baseURL, "https://"), "http://")
+}
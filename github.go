@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+type githubProvider struct {
+	// API URL of a Github Enterprise Server, empty for --api-url
+	baseURL   string
+	token     string
+	maxPages  int
+	limit     int
+	rateLimit RateLimit
+	// ETag sent with the first page and the one it returned
+	ifNoneMatch string
+	etag        string
+	// Seconds between polls Github asks for with X-Poll-Interval
+	pollInterval time.Duration
+	// FeedOrg, FeedRepo or FeedReceived when not the events of users
+	feed string
+	// ID of the newest stored event, paging stops at it
+	stopAt string
+}
+
+func init() {
+	RegisterProvider("github", func(config ProviderConfig) (Provider, error) {
+		maxPages := config.Pages
+		if maxPages <= 0 {
+			maxPages = 1
+			// A limit alone fetches as many pages as it takes
+			if config.Limit > 0 {
+				maxPages = github.MaxEventPages
+			}
+		}
+		token := config.Token
+		if token == "" {
+			token = defaultProviderToken("github", config.BaseURL)
+		}
+		var baseURL string
+		if config.BaseURL != "" {
+			var err error
+			baseURL, err = github.NormalizeBaseURL(config.BaseURL)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &githubProvider{baseURL: baseURL, token: token, maxPages: maxPages, limit: config.Limit, feed: config.Feed, stopAt: config.StopAt}, nil
+	})
+}
+
+func (p *githubProvider) Name() string {
+	return "github"
+}
+
+func (p *githubProvider) FetchEvents(username string) ([]json.RawMessage, error) {
+	var events []json.RawMessage
+	err := p.FetchEventPages(username, func(page []json.RawMessage) {
+		events = append(events, page...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Follow the Link header up to maxPages, stopping early once limit events
+// were fetched
+func (p *githubProvider) FetchEventPages(username string, onPage func([]json.RawMessage)) error {
+	options := github.EventsOptions{MaxPages: p.maxPages, Limit: p.limit, IfNoneMatch: p.ifNoneMatch, StopAt: p.stopAt}
+	// Bigger pages take fewer requests when more than the default 30 are wanted
+	if p.limit > 30 {
+		options.PerPage = github.MaxPerPage
+	}
+
+	client := newGithubClient(p.token)
+	if p.baseURL != "" {
+		client.BaseURL = p.baseURL
+	}
+	eventPages := client.UserEventPages
+	switch p.feed {
+	case FeedOrg:
+		eventPages = client.OrgEventPages
+	case FeedRepo:
+		eventPages = client.RepoEventPages
+	case FeedReceived:
+		eventPages = client.ReceivedEventPages
+	}
+
+	first := true
+	header, err := eventPages(username, options, func(events []json.RawMessage, header http.Header) {
+		p.rateLimit = github.ParseRateLimit(header, "X-RateLimit-")
+		if first {
+			p.etag = header.Get("ETag")
+			p.pollInterval = parsePollInterval(header)
+			first = false
+		}
+		warnPartialResults(header)
+		onPage(events)
+	})
+	if err != nil {
+		p.rateLimit = github.ParseRateLimit(header, "X-RateLimit-")
+		// A 304 still tells how long to wait before the next poll
+		if interval := parsePollInterval(header); interval > 0 {
+			p.pollInterval = interval
+		}
+	}
+	if isNotFound(err) && (p.feed == FeedUser || p.feed == FeedReceived) {
+		if suggestion := suggestUsername(username); suggestion != "" {
+			return fmt.Errorf("%v, did you mean %s?", err, suggestion)
+		}
+	}
+
+	return err
+}
+
+func parsePollInterval(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("X-Poll-Interval"))
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+func (p *githubProvider) PollInterval() time.Duration {
+	return p.pollInterval
+}
+
+func (p *githubProvider) SetETag(etag string) {
+	p.ifNoneMatch = etag
+}
+
+func (p *githubProvider) ETag() string {
+	return p.etag
+}
+
+func (p *githubProvider) Normalize(raw json.RawMessage) (Event, error) {
+	var githubEvent github.Event
+	err := json.Unmarshal(raw, &githubEvent)
+	if err != nil {
+		return Event{}, err
+	}
+	payload, err := githubEvent.DecodePayload()
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := Event{
+		ID:        githubEvent.ID,
+		Provider:  p.Name(),
+		Type:      githubEvent.Type,
+		Action:    ActionOther,
+		Target:    EventTarget{Kind: TargetUnknown},
+		Actor:     EventActor{Login: githubEvent.Actor.Login},
+		Repo:      EventRepo{Name: githubEvent.Repo.Name, URL: githubEvent.Repo.URL},
+		CreatedAt: githubEvent.CreatedAt,
+		Raw:       raw,
+	}
+
+	switch payload := payload.(type) {
+	case *github.PushEventPayload:
+		event.Action = ActionPushed
+		event.Target.Kind = TargetBranch
+		event.Ref = strings.TrimPrefix(payload.Ref, "refs/heads/")
+		event.Size = payload.Size
+		for _, commit := range payload.Commits {
+			event.Commits = append(event.Commits, EventCommit{SHA: commit.SHA, Message: commit.Message})
+		}
+	case *github.CreateEventPayload:
+		event.Action = ActionCreated
+		event.Target.Kind = payload.RefType
+		event.Ref = payload.Ref
+	case *github.DeleteEventPayload:
+		event.Action = ActionDeleted
+		event.Target.Kind = payload.RefType
+		event.Ref = payload.Ref
+	case *github.IssuesEventPayload:
+		event.Action = githubAction(payload.Action)
+		setIssueTarget(&event, payload.Issue)
+	case *github.IssueCommentEventPayload:
+		event.Action = ActionCommented
+		setIssueTarget(&event, payload.Issue)
+	case *github.PullRequestEventPayload:
+		event.Action = githubAction(payload.Action)
+		if event.Action == ActionClosed && payload.PullRequest.Merged {
+			event.Action = ActionMerged
+		}
+		setPullRequestTarget(&event, payload.PullRequest)
+	case *github.PullRequestReviewEventPayload:
+		event.Action = ActionReviewed
+		setPullRequestTarget(&event, payload.PullRequest)
+	case *github.PullRequestReviewCommentEventPayload:
+		event.Action = ActionCommented
+		setPullRequestTarget(&event, payload.PullRequest)
+	case *github.CommitCommentEventPayload:
+		event.Action = ActionCommented
+		event.Target.Kind = TargetComment
+		event.Target.URL = payload.Comment.HTMLURL
+	case *github.WatchEventPayload:
+		event.Action = ActionStarred
+		event.Target.Kind = TargetRepository
+	case *github.ForkEventPayload:
+		event.Action = ActionForked
+		event.Target.Kind = TargetRepository
+		event.Target.Title = payload.Forkee.FullName
+		event.Target.URL = payload.Forkee.HTMLURL
+	case *github.ReleaseEventPayload:
+		event.Action = ActionReleased
+		event.Target.Kind = TargetRelease
+		event.Ref = payload.Release.TagName
+		event.Target.Title = payload.Release.Name
+		event.Target.URL = payload.Release.HTMLURL
+	case *github.MemberEventPayload:
+		event.Action = ActionJoined
+		event.Target.Kind = TargetMember
+		event.Target.Title = payload.Member.Login
+	case *github.PublicEventPayload:
+		event.Action = ActionOpened
+		event.Target.Kind = TargetRepository
+	}
+
+	return event, nil
+}
+
+func setIssueTarget(event *Event, issue github.Issue) {
+	event.Target.Kind = TargetIssue
+	event.Target.Number = issue.Number
+	event.Target.Title = issue.Title
+	event.Target.URL = issue.HTMLURL
+}
+
+func setPullRequestTarget(event *Event, pullRequest github.PullRequest) {
+	event.Target.Kind = TargetPullRequest
+	event.Target.Number = pullRequest.Number
+	event.Target.Title = pullRequest.Title
+	event.Target.URL = pullRequest.HTMLURL
+}
+
+// Map the payload action of issue and pull request events to a verb
+func githubAction(action string) string {
+	switch action {
+	case "opened":
+		return ActionOpened
+	case "closed":
+		return ActionClosed
+	case "reopened":
+		return ActionReopened
+	case "created":
+		return ActionCommented
+	default:
+		return ActionOther
+	}
+}
+
+func (p *githubProvider) RateLimitInfo() RateLimit {
+	return p.rateLimit
+}
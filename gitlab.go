@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+const defaultGitlabBaseURL = "https://gitlab.com"
+
+// Events per page when the API isn't asked for more
+const gitlabDefaultPerPage = 20
+
+type GitlabEvent struct {
+	ID          int       `json:"id"`
+	ProjectID   int       `json:"project_id"`
+	ActionName  string    `json:"action_name"`
+	TargetType  string    `json:"target_type"`
+	TargetIID   int       `json:"target_iid"`
+	TargetTitle string    `json:"target_title"`
+	CreatedAt   time.Time `json:"created_at"`
+	Author      struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	PushData *struct {
+		Ref         string `json:"ref"`
+		RefType     string `json:"ref_type"`
+		CommitTo    string `json:"commit_to"`
+		CommitTitle string `json:"commit_title"`
+		CommitCount int    `json:"commit_count"`
+	} `json:"push_data"`
+	Note *struct {
+		NoteableType string `json:"noteable_type"`
+		NoteableIID  int    `json:"noteable_iid"`
+	} `json:"note"`
+}
+
+type GitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+type gitlabProvider struct {
+	token     string
+	baseURL   string
+	maxPages  int
+	limit     int
+	rateLimit RateLimit
+	// ID of the newest stored event, paging stops at it
+	stopAt string
+	// FeedRepo for the events of projects given as namespace/name
+	feed string
+
+	// Events only reference the project by ID, so every project is looked up once
+	projects      map[int]GitlabProject
+	projectsMutex sync.Mutex
+}
+
+func init() {
+	RegisterProvider("gitlab", func(config ProviderConfig) (Provider, error) {
+		if config.Feed != FeedUser && config.Feed != FeedRepo {
+			return nil, fmt.Errorf("gitlab has no %s events, only the events of users and projects", config.Feed)
+		}
+
+		provider := newGitlabProvider(config.Token, config.BaseURL)
+		provider.maxPages = config.Pages
+		if provider.maxPages <= 0 {
+			provider.maxPages = 1
+			// A limit alone fetches as many pages as it takes
+			if config.Limit > 0 {
+				provider.maxPages = github.MaxEventPages
+			}
+		}
+		provider.limit = config.Limit
+		provider.feed = config.Feed
+		provider.stopAt = config.StopAt
+		return provider, nil
+	})
+}
+
+func newGitlabProvider(token string, baseURL string) *gitlabProvider {
+	if baseURL == "" {
+		baseURL = defaultGitlabBaseURL
+	}
+	if token == "" {
+		token = defaultProviderToken("gitlab", baseURL)
+	}
+
+	return &gitlabProvider{
+		token:    token,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		maxPages: 1,
+		projects: make(map[int]GitlabProject),
+	}
+}
+
+func (p *gitlabProvider) Name() string {
+	if p.baseURL == defaultGitlabBaseURL {
+		return "gitlab"
+	}
+
+	return "gitlab@" + hostOf(p.baseURL)
+}
+
+func (p *gitlabProvider) getJSON(path string, v interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(interrupted, http.MethodGet, p.baseURL+"/api/v4"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", p.token)
+	}
+
+	header, err := doJSONRequest(req, v)
+	if header != nil {
+		p.rateLimit = github.ParseRateLimit(header, "RateLimit-")
+	}
+	return header, err
+}
+
+func (p *gitlabProvider) FetchEvents(username string) ([]json.RawMessage, error) {
+	var events []json.RawMessage
+	err := p.FetchEventPages(username, func(page []json.RawMessage) {
+		events = append(events, page...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Follow X-Next-Page up to maxPages, stopping early once limit events were
+// fetched. The events of a project are fetched with its namespace/name, the
+// API takes it escaped in place of the project ID.
+func (p *gitlabProvider) FetchEventPages(name string, onPage func([]json.RawMessage)) error {
+	eventsPath := fmt.Sprintf("/users/%s/events", url.PathEscape(name))
+	if p.feed == FeedRepo {
+		eventsPath = fmt.Sprintf("/projects/%s/events", url.PathEscape(name))
+	}
+	perPage := gitlabDefaultPerPage
+	// Bigger pages take fewer requests when more than the default are wanted
+	if p.limit > gitlabDefaultPerPage {
+		perPage = github.MaxPerPage
+	}
+
+	fetched := 0
+	nextPage := "1"
+	for page := 0; page < p.maxPages && nextPage != ""; page++ {
+		params := url.Values{}
+		params.Set("page", nextPage)
+		params.Set("per_page", strconv.Itoa(perPage))
+
+		var events []json.RawMessage
+		header, err := p.getJSON(eventsPath+"?"+params.Encode(), &events)
+		if err != nil {
+			return err
+		}
+		if p.limit > 0 && fetched+len(events) > p.limit {
+			events = events[:p.limit-fetched]
+		}
+		events, known := eventsBefore(events, p.stopAt)
+		fetched += len(events)
+		onPage(events)
+
+		if known || p.limit > 0 && fetched >= p.limit {
+			break
+		}
+		nextPage = header.Get("X-Next-Page")
+	}
+
+	return nil
+}
+
+func (p *gitlabProvider) project(id int) GitlabProject {
+	p.projectsMutex.Lock()
+	defer p.projectsMutex.Unlock()
+
+	if project, ok := p.projects[id]; ok {
+		return project
+	}
+
+	var project GitlabProject
+	_, err := p.getJSON(fmt.Sprintf("/projects/%d", id), &project)
+	if err != nil {
+		// Projects we can't see still show up with their ID
+		project.PathWithNamespace = "project-" + strconv.Itoa(id)
+	}
+	p.projects[id] = project
+
+	return project
+}
+
+func (p *gitlabProvider) Normalize(raw json.RawMessage) (Event, error) {
+	var gitlabEvent GitlabEvent
+	err := json.Unmarshal(raw, &gitlabEvent)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := Event{
+		ID:        strconv.Itoa(gitlabEvent.ID),
+		Provider:  p.Name(),
+		Type:      gitlabEventType(gitlabEvent),
+		Action:    gitlabAction(gitlabEvent.ActionName),
+		Target:    EventTarget{Kind: gitlabTargetKind(gitlabEvent.TargetType), Number: gitlabEvent.TargetIID, Title: gitlabEvent.TargetTitle},
+		Actor:     EventActor{Login: gitlabEvent.Author.Username},
+		CreatedAt: gitlabEvent.CreatedAt,
+		Raw:       raw,
+	}
+	if gitlabEvent.ProjectID != 0 {
+		project := p.project(gitlabEvent.ProjectID)
+		event.Repo = EventRepo{Name: project.PathWithNamespace, URL: project.WebURL}
+	}
+	if gitlabEvent.PushData != nil {
+		event.Ref = gitlabEvent.PushData.Ref
+		event.Target.Kind = gitlabEvent.PushData.RefType
+		event.Size = gitlabEvent.PushData.CommitCount
+		if gitlabEvent.PushData.CommitTitle != "" {
+			// Gitlab only sends the title of the last commit of a push
+			event.Commits = []EventCommit{{SHA: gitlabEvent.PushData.CommitTo, Message: gitlabEvent.PushData.CommitTitle}}
+		}
+	}
+	if gitlabEvent.Note != nil {
+		// Comments are reported against the issue or merge request they belong to
+		event.Target.Kind = gitlabTargetKind(gitlabEvent.Note.NoteableType)
+		event.Target.Number = gitlabEvent.Note.NoteableIID
+	}
+	if event.Target.Kind == TargetUnknown && event.Action == ActionCreated {
+		event.Target.Kind = TargetRepository
+	}
+
+	return event, nil
+}
+
+func (p *gitlabProvider) RateLimitInfo() RateLimit {
+	return p.rateLimit
+}
+
+// Translate a Gitlab action into the closest Github event type
+func gitlabEventType(event GitlabEvent) string {
+	switch {
+	case strings.HasPrefix(event.ActionName, "pushed"):
+		return "PushEvent"
+	case event.ActionName == "commented on":
+		return "IssueCommentEvent"
+	case event.ActionName == "joined":
+		return "MemberEvent"
+	case event.ActionName == "deleted":
+		return "DeleteEvent"
+	case event.TargetType == "MergeRequest":
+		return "PullRequestEvent"
+	case event.TargetType == "Issue":
+		return "IssuesEvent"
+	case event.ActionName == "created":
+		return "CreateEvent"
+	default:
+		return "GitlabEvent"
+	}
+}
+
+func gitlabAction(actionName string) string {
+	switch {
+	case strings.HasPrefix(actionName, "pushed"):
+		return ActionPushed
+	case actionName == "created":
+		return ActionCreated
+	case actionName == "deleted":
+		return ActionDeleted
+	case actionName == "opened":
+		return ActionOpened
+	case actionName == "closed":
+		return ActionClosed
+	case actionName == "reopened":
+		return ActionReopened
+	case actionName == "accepted", actionName == "merged":
+		return ActionMerged
+	case actionName == "commented on":
+		return ActionCommented
+	case actionName == "approved":
+		return ActionReviewed
+	case actionName == "joined":
+		return ActionJoined
+	default:
+		return ActionOther
+	}
+}
+
+func gitlabTargetKind(targetType string) string {
+	switch targetType {
+	case "Issue", "WorkItem":
+		return TargetIssue
+	case "MergeRequest":
+		return TargetPullRequest
+	case "Note", "DiffNote", "DiscussionNote":
+		return TargetComment
+	case "Milestone", "":
+		return TargetUnknown
+	default:
+		return strings.ToLower(targetType)
+	}
+}
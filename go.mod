@@ -1 +1 @@
-module github-activity-cli
+module github.com/febryansambuari/github-activity-cli
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// The events per local day from the day of the oldest event, or from, to the
+// day of to, with the days without events
+func eventsPerDay(events []Event, from time.Time, to time.Time) []ContributionDay {
+	if len(events) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	first := to
+	for _, event := range events {
+		local := event.CreatedAt.Local()
+		counts[local.Format(dayLayout)]++
+		if local.Before(first) {
+			first = local
+		}
+	}
+	if !from.IsZero() {
+		first = from.Local()
+	}
+
+	var days []ContributionDay
+	last := to.Local().Format(dayLayout)
+	for day := first; ; day = day.AddDate(0, 0, 1) {
+		date := day.Format(dayLayout)
+		days = append(days, ContributionDay{Date: date, Count: counts[date]})
+		if date >= last {
+			break
+		}
+	}
+
+	return days
+}
+
+// The last day drawn, today unless --until ends the period before
+func graphEnd(filter EventFilter) time.Time {
+	now := time.Now()
+	// The until bound is exclusive
+	if !filter.Until.IsZero() && filter.Until.Before(now) {
+		return filter.Until.Add(-time.Nanosecond)
+	}
+
+	return now
+}
+
+// graph <username> draws the events of the fetched period as a calendar
+// heatmap, the contributions command draws the one of Github profiles
+func runGraph(args []string) {
+	flags := newFlagSet("graph")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	applyProvider := addProviderFlags(flags)
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	var types stringList
+	flags.Var(&types, "type", "only count events of this type, e.g. PushEvent, can be repeated or comma-separated (replaces the preset types)")
+	applyDateRange := addDateRangeFlags(flags)
+	applyFilters := addFilterFlags(flags)
+	pages := flags.Int("pages", 0, "maximum number of pages to fetch (default 1 for github)")
+	color := flags.Bool("color", false, "shade the days in greens instead of only block characters")
+	output := flags.String("output", "", "write the chart to this .svg or .png file instead of drawing it in the terminal")
+	chartKind := flags.String("chart", "heatmap", "chart --output draws: heatmap or bars of the days")
+	applySource := addSourceFlag(flags)
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	applyStore := addStoreFlags(flags, "none")
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		log.Fatalf("Usage: graph [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 30d] [--pages n] [--color] [--source events|graphql] [--output chart.svg] [--chart heatmap|bars] [--store file] [--timeout 10s] [--deadline 2m] [--retries 3] <username>")
+	}
+	if *output != "" {
+		err := validateChartOutput(*output, *chartKind)
+		if err != nil {
+			log.Fatalf("Error parsing --output: %v", err)
+		}
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	providerConfig := ProviderConfig{Pages: *pages}
+	applyProvider(settings, &providerConfig)
+	applyTimeouts(settings)
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+	if len(types) > 0 {
+		filter.Types = types
+	}
+	applyDateRange(&filter)
+	applyFilters(&filter)
+	source := applySource(filter, *providerName)
+	provider, err := newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+	token := ""
+	if source == "graphql" {
+		token = graphQLToken(providerConfig.Token)
+	}
+
+	applyCache(settings)
+	applyStore(settings)
+	loadCache()
+
+	usernames := expandUsername(config, flags.Arg(0))
+	if *output != "" && len(usernames) > 1 {
+		log.Fatalf("--output charts one user, %s is a list of %d", flags.Arg(0), len(usernames))
+	}
+
+	for _, username := range usernames {
+		unit := "event"
+		var days []ContributionDay
+		if source == "graphql" {
+			from, to := contributionPeriod(filter)
+			contributions, err := fetchContributionsBetween(token, username, from, to)
+			if err != nil {
+				exitIfInterrupted()
+				log.Fatalf("Error fetching contributions of %s: %v", username, err)
+			}
+
+			fmt.Printf("User: %s\n", username)
+			printContributionCounts(contributions)
+			unit = "contribution"
+			days = contributions.Days()
+		} else {
+			events, err := getEvents(provider, username)
+			if err != nil {
+				exitIfInterrupted()
+				log.Fatalf("Error fetching events of %s: %v", username, err)
+			}
+			events = withStoredEvents(provider, username, events)
+			events = filter.Apply(events)
+
+			fmt.Printf("User: %s\n", username)
+			fmt.Printf("Total Events: %d\n", len(events))
+			days = eventsPerDay(events, filter.Since, graphEnd(filter))
+		}
+		if len(days) > 0 {
+			fmt.Printf("From: %s\n", days[0].Date)
+			fmt.Printf("To: %s\n", days[len(days)-1].Date)
+		}
+		if *output != "" {
+			if len(days) == 0 {
+				log.Fatalf("No activity of %s to chart", username)
+			}
+			title := fmt.Sprintf("Activity of %s from %s to %s", username, days[0].Date, days[len(days)-1].Date)
+			err := writeChart(*output, *chartKind, title, unit, days)
+			if err != nil {
+				log.Fatalf("Error writing chart: %v", err)
+			}
+			fmt.Printf("Chart: %s\n", *output)
+		} else if len(days) > 0 {
+			fmt.Println()
+			for _, line := range renderHeatmap(days, *color) {
+				fmt.Println(line)
+			}
+			legend := make([]string, len(heatmapShades))
+			for shade := range heatmapShades {
+				legend[shade] = heatmapShade(shade, *color)
+			}
+			fmt.Printf("    Less %s More\n", strings.Join(legend, ""))
+		}
+		fmt.Println("----------------------")
+	}
+
+	saveCache()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+)
+
+// How the events are ordered and grouped, set with --sort and --group-by
+type eventOrder struct {
+	// repo, type or day, empty for a flat list
+	groupBy string
+	// Oldest first instead of newest first
+	ascending bool
+}
+
+// A run of events with the same repository, type or day
+type EventGroup struct {
+	Group  string  `json:"group"`
+	Events []Event `json:"events"`
+}
+
+// Register --group-by and --sort on a command that prints events, the
+// returned function checks them
+func addOrderFlags(flags *flag.FlagSet) func() eventOrder {
+	groupBy := flags.String("group-by", "", "print the events under a header per repo, type or day")
+	order := flags.String("sort", "desc", "order of the events: desc for newest first or asc for oldest first")
+
+	return func() eventOrder {
+		switch *groupBy {
+		case "", "repo", "type", "day":
+		default:
+			log.Fatalf("Unknown --group-by %q, expected repo, type or day", *groupBy)
+		}
+		if *order != "asc" && *order != "desc" {
+			log.Fatalf("Unknown --sort %q, expected asc or desc", *order)
+		}
+
+		return eventOrder{groupBy: *groupBy, ascending: *order == "asc"}
+	}
+}
+
+// Whether the events can be printed as they arrive, newest first and flat
+func (o eventOrder) streams() bool {
+	return o.groupBy == "" && !o.ascending
+}
+
+// Sort the events newest or oldest first, events of the same time keep their
+// order
+func (o eventOrder) sort(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if o.ascending {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+}
+
+func (o eventOrder) groupKey(event Event) string {
+	switch o.groupBy {
+	case "repo":
+		return event.Repo.Name
+	case "type":
+		return event.Type
+	default:
+		location := displayLocation
+		if location == nil {
+			location = time.Local
+		}
+		return event.CreatedAt.In(location).Format(dayLayout)
+	}
+}
+
+// Split sorted events in groups, in the order each group first appears so
+// the groups follow the sort order too
+func (o eventOrder) group(events []Event) []EventGroup {
+	groups := []EventGroup{}
+	index := make(map[string]int)
+	for _, event := range events {
+		key := o.groupKey(event)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, EventGroup{Group: key})
+		}
+		groups[i].Events = append(groups[i].Events, event)
+	}
+
+	return groups
+}
+
+// Print the events as text with printEvent, under a header per group when
+// grouped
+func (o eventOrder) print(w io.Writer, renderer *eventRenderer, events []Event, printEvent func(event Event)) {
+	if o.groupBy == "" {
+		for _, event := range events {
+			printEvent(event)
+		}
+		return
+	}
+
+	for _, group := range o.group(events) {
+		fmt.Fprintf(w, "-- %s (%d %s) --\n", renderer.paint(ansiBold, group.Group), len(group.Events), plural(len(group.Events), "event"))
+		for _, event := range group.Events {
+			printEvent(event)
+		}
+	}
+}
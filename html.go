@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The HTML report of fetch --format html, laid out like the Markdown one
+type HTMLReport struct {
+	Heading  string
+	Overview string
+	// Inline SVG heatmap of the days from the oldest event to the newest
+	Heatmap template.HTML
+	Repos   []HTMLReportRepo
+	Notes   []HTMLReportNote
+}
+
+type HTMLReportRepo struct {
+	Name string
+	URL  string
+	// The number of events in words, e.g. 3 events
+	Count  string
+	Events []HTMLReportEvent
+}
+
+type HTMLReportEvent struct {
+	Date    string
+	Time    string
+	Text    string
+	URL     string
+	Title   string
+	Actor   string
+	Type    string
+	Commits []HTMLReportCommit
+	Notes   []string
+}
+
+type HTMLReportCommit struct {
+	SHA     string
+	Message string
+}
+
+type HTMLReportNote struct {
+	Day  string
+	Text string
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Heading}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 960px; margin: 2em auto; padding: 0 1em; line-height: 1.5; }
+a { color: #0969da; text-decoration: none; }
+a:hover { text-decoration: underline; }
+h1 { border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+h2 { font-size: 1.2em; margin-top: 1.5em; }
+.count { color: #656d76; font-weight: normal; font-size: .85em; }
+.heatmap { overflow-x: auto; margin: 1em 0; }
+details { border: 1px solid #d0d7de; border-radius: 6px; margin: .4em 0; padding: .4em .8em; }
+details[open] summary { margin-bottom: .4em; }
+summary { cursor: pointer; }
+.date { color: #656d76; font-variant-numeric: tabular-nums; margin-right: .5em; }
+.meta { color: #656d76; font-size: .85em; }
+code { background: #f6f8fa; border-radius: 4px; padding: .1em .3em; }
+ul { margin: .3em 0; }
+</style>
+</head>
+<body>
+<h1>{{.Heading}}</h1>
+<p>{{.Overview}}</p>
+{{if .Heatmap}}<div class="heatmap">{{.Heatmap}}</div>
+{{end}}{{range .Repos}}<section>
+<h2><a href="{{.URL}}">{{.Name}}</a> <span class="count">{{.Count}}</span></h2>
+{{range .Events}}<details>
+<summary><span class="date">{{.Date}}</span>{{.Text}}{{if .Title}}: {{.Title}}{{end}}{{if .Actor}} by {{.Actor}}{{end}}</summary>
+<p class="meta">{{.Type}} at {{.Time}}{{if .URL}} · <a href="{{.URL}}">view on the forge</a>{{end}}</p>
+{{if .Commits}}<ul>
+{{range .Commits}}<li><code>{{.SHA}}</code> {{.Message}}</li>
+{{end}}</ul>
+{{end}}{{range .Notes}}<p><em>Note:</em> {{.}}</p>
+{{end}}</details>
+{{end}}</section>
+{{end}}{{if .Notes}}<h2>Notes</h2>
+<ul>
+{{range .Notes}}<li><strong>{{.Day}}</strong> {{.Text}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+// Build the HTML report: a section per repository, most recently active
+// first, with a collapsible entry per event showing its commits and notes,
+// the heatmap of the days and the notes of the days
+func newHTMLReport(names []string, events []Event) HTMLReport {
+	sorted := make([]Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	report := HTMLReport{Heading: "Activity of " + strings.Join(names, ", ")}
+	if len(sorted) == 0 {
+		report.Overview = "No events."
+		return report
+	}
+	first := sorted[len(sorted)-1].CreatedAt.Local().Format(dayLayout)
+	last := sorted[0].CreatedAt.Local().Format(dayLayout)
+	report.Overview = fmt.Sprintf("%d %s from %s to %s", len(sorted), plural(len(sorted), "event"), first, last)
+	days := eventsPerDay(sorted, time.Time{}, sorted[0].CreatedAt)
+	report.Heatmap = template.HTML(heatmapChart(days).svg(report.Heading, "event"))
+
+	actors := make(map[string]bool)
+	for _, event := range sorted {
+		actors[event.Actor.Login] = true
+	}
+	index := make(map[string]int)
+	for _, event := range sorted {
+		i, found := index[event.Repo.Name]
+		if !found {
+			i = len(report.Repos)
+			index[event.Repo.Name] = i
+			report.Repos = append(report.Repos, HTMLReportRepo{Name: event.Repo.Name, URL: repoHTMLURL(event)})
+		}
+
+		entry := HTMLReportEvent{
+			Date: event.CreatedAt.Local().Format(dayLayout),
+			Time: event.CreatedAt.Local().Format("2006-01-02 15:04 MST"),
+			// The section already names the repository
+			Text: strings.TrimSuffix(describeEvent(event), " in "+event.Repo.Name),
+			URL:  eventHTMLURL(event),
+			Type: event.Type,
+		}
+		if event.Target.Title != "" && event.Action != ActionForked && event.Action != ActionJoined {
+			entry.Title = event.Target.Title
+		}
+		if len(actors) > 1 {
+			entry.Actor = event.Actor.Login
+		}
+		for _, commit := range event.Commits {
+			entry.Commits = append(entry.Commits, HTMLReportCommit{
+				SHA:     shortSHA(commit.SHA),
+				Message: strings.SplitN(commit.Message, "\n", 2)[0],
+			})
+		}
+		for _, note := range eventNotes(event.ID) {
+			entry.Notes = append(entry.Notes, note.Text)
+		}
+		report.Repos[i].Events = append(report.Repos[i].Events, entry)
+	}
+	for i, repo := range report.Repos {
+		report.Repos[i].Count = fmt.Sprintf("%d %s", len(repo.Events), plural(len(repo.Events), "event"))
+	}
+
+	seen := make(map[string]bool)
+	for _, event := range sorted {
+		day := event.CreatedAt.Local().Format(dayLayout)
+		if seen[day] {
+			continue
+		}
+		seen[day] = true
+		for _, note := range dayNotes(day) {
+			report.Notes = append(report.Notes, HTMLReportNote{Day: day, Text: note.Text})
+		}
+	}
+
+	return report
+}
+
+// Write the events as a standalone HTML report, the styles and the heatmap
+// are inline so the file can be attached or mailed on its own
+func writeHTMLReport(w io.Writer, names []string, events []Event) error {
+	return htmlReportTemplate.Execute(w, newHTMLReport(names, events))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Times of an iCalendar file, in UTC
+const icsTimeLayout = "20060102T150405Z"
+
+// Events last this long on the calendar, so calendar apps draw them
+const icsEventDuration = 15 * time.Minute
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Writes the content lines of an iCalendar file: CRLF line endings, and
+// lines folded at 75 octets without splitting a UTF-8 character
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *icsWriter) line(name string, value string) {
+	if w.err != nil {
+		return
+	}
+
+	line := name + ":" + value
+	var folded strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+	folded.WriteString("\r\n")
+	_, w.err = io.WriteString(w.w, folded.String())
+}
+
+func (w *icsWriter) begin(names []string) {
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//github-activity-cli//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("X-WR-CALNAME", icsEscaper.Replace("Activity of "+strings.Join(names, ", ")))
+}
+
+func (w *icsWriter) end() error {
+	w.line("END", "VCALENDAR")
+	return w.err
+}
+
+// Write the events as an iCalendar file with a VEVENT per event, at the
+// time it happened and linking to it, to overlay on a calendar
+func writeICalendar(w io.Writer, names []string, events []Event) error {
+	ics := &icsWriter{w: w}
+	stamp := time.Now().UTC().Format(icsTimeLayout)
+
+	ics.begin(names)
+	for _, event := range events {
+		start := event.CreatedAt.UTC()
+		var description []string
+		if event.Target.Title != "" {
+			description = append(description, event.Target.Title)
+		}
+		for _, commit := range event.Commits {
+			message := strings.SplitN(commit.Message, "\n", 2)[0]
+			description = append(description, shortSHA(commit.SHA)+" "+message)
+		}
+
+		uid := event.ID
+		if event.Provider != "" {
+			uid = event.Provider + "-" + uid
+		}
+
+		ics.line("BEGIN", "VEVENT")
+		ics.line("UID", icsEscaper.Replace(uid+"@github-activity-cli"))
+		ics.line("DTSTAMP", stamp)
+		ics.line("DTSTART", start.Format(icsTimeLayout))
+		ics.line("DTEND", start.Add(icsEventDuration).Format(icsTimeLayout))
+		ics.line("SUMMARY", icsEscaper.Replace(describeEvent(event)))
+		if len(description) > 0 {
+			ics.line("DESCRIPTION", icsEscaper.Replace(strings.Join(description, "\n")))
+		}
+		ics.line("URL", eventHTMLURL(event))
+		ics.line("CATEGORIES", icsEscaper.Replace(event.Type))
+		ics.line("TRANSP", "TRANSPARENT")
+		ics.line("END", "VEVENT")
+	}
+
+	return ics.end()
+}
+
+// Write an all-day VEVENT per local day with activity of each user, counting
+// the events of the day per type
+func writeICalendarDays(w io.Writer, names []string, eventsByUser [][]Event) error {
+	ics := &icsWriter{w: w}
+	stamp := time.Now().UTC().Format(icsTimeLayout)
+
+	ics.begin(names)
+	for i, username := range names {
+		var days []string
+		byDay := make(map[string]map[string]int)
+		totals := make(map[string]int)
+		for _, event := range eventsByUser[i] {
+			day := event.CreatedAt.Local().Format(dayLayout)
+			if byDay[day] == nil {
+				byDay[day] = make(map[string]int)
+				days = append(days, day)
+			}
+			byDay[day][event.Type]++
+			totals[day]++
+		}
+		sort.Strings(days)
+
+		for _, day := range days {
+			date, _ := time.Parse(dayLayout, day)
+			var types []string
+			for eventType := range byDay[day] {
+				types = append(types, eventType)
+			}
+			sort.Strings(types)
+			description := make([]string, 0, len(types))
+			for _, eventType := range types {
+				description = append(description, fmt.Sprintf("%s: %d", eventType, byDay[day][eventType]))
+			}
+
+			ics.line("BEGIN", "VEVENT")
+			ics.line("UID", icsEscaper.Replace(username+"-"+day+"@github-activity-cli"))
+			ics.line("DTSTAMP", stamp)
+			ics.line("DTSTART;VALUE=DATE", date.Format("20060102"))
+			ics.line("DTEND;VALUE=DATE", date.AddDate(0, 0, 1).Format("20060102"))
+			ics.line("SUMMARY", icsEscaper.Replace(fmt.Sprintf("%s: %d %s", username, totals[day], plural(totals[day], "event"))))
+			ics.line("DESCRIPTION", icsEscaper.Replace(strings.Join(description, "\n")))
+			ics.line("TRANSP", "TRANSPARENT")
+			ics.line("END", "VEVENT")
+		}
+	}
+
+	return ics.end()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Context of every request, canceled by the first Ctrl+C once a command
+// called cancelOnInterrupt, or with context.DeadlineExceeded once --deadline
+// is over
+var interrupted, interrupt = context.WithCancelCause(context.Background())
+
+// Cancel the requests on the first Ctrl+C or SIGTERM instead of exiting, so
+// the command can save what was fetched and stop. A second one exits at once.
+func cancelOnInterrupt() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fetchProgress.Stop()
+		logger.Warn("Interrupted, stopping the requests and saving the cache, press Ctrl+C again to quit now")
+		interrupt(context.Canceled)
+
+		<-signals
+		os.Exit(130)
+	}()
+}
+
+// Save the cache and exit with the status of an interrupt when the requests
+// were canceled, the errors they returned are only about that. An exceeded
+// deadline exits with 1 like the other errors.
+func exitIfInterrupted() {
+	if interrupted.Err() == nil {
+		return
+	}
+
+	fetchProgress.Stop()
+	saveCache()
+	if errors.Is(context.Cause(interrupted), context.DeadlineExceeded) {
+		os.Exit(1)
+	}
+	os.Exit(130)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity of a diagnostic
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// Writes diagnostics to stderr so stdout only has the output of the command.
+// Warnings and errors are written by default, --verbose adds the info and
+// debug ones and --quiet keeps only the errors. Every command takes the flags.
+type Logger struct {
+	mutex   sync.Mutex
+	output  io.Writer
+	Verbose bool
+	Quiet   bool
+	// One JSON object per line instead of text, set with --log-format json
+	JSON bool
+}
+
+var logger = &Logger{output: os.Stderr}
+
+// SetOutput changes where the diagnostics go, the dashboard discards them so
+// they don't break the screen
+func (l *Logger) SetOutput(w io.Writer) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.output = w
+}
+
+// Enabled reports whether diagnostics of the level are written
+func (l *Logger) Enabled(level LogLevel) bool {
+	switch {
+	case l.Quiet:
+		return level >= LevelError
+	case l.Verbose:
+		return true
+	default:
+		return level >= LevelWarn
+	}
+}
+
+func (l *Logger) Debug(message string, fields ...interface{}) {
+	l.log(LevelDebug, message, fields)
+}
+
+func (l *Logger) Info(message string, fields ...interface{}) {
+	l.log(LevelInfo, message, fields)
+}
+
+func (l *Logger) Warn(message string, fields ...interface{}) {
+	l.log(LevelWarn, message, fields)
+}
+
+func (l *Logger) Error(message string, fields ...interface{}) {
+	l.log(LevelError, message, fields)
+}
+
+// Write a message with fields given as key, value pairs
+func (l *Logger) log(level LogLevel, message string, fields []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	var line string
+	if l.JSON {
+		line = formatLogJSON(level, message, fields)
+	} else {
+		line = formatLogText(level, message, fields)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.output == os.Stderr {
+		fetchProgress.Clear()
+	}
+	fmt.Fprintln(l.output, line)
+}
+
+// Info messages read as plain output, the other levels get a prefix
+var logTextPrefixes = map[LogLevel]string{
+	LevelDebug: "Debug: ",
+	LevelWarn:  "Warning: ",
+	LevelError: "Error: ",
+}
+
+func formatLogText(level LogLevel, message string, fields []interface{}) string {
+	var line strings.Builder
+	line.WriteString(logTextPrefixes[level])
+	line.WriteString(message)
+	for i := 0; i+1 < len(fields); i += 2 {
+		value := logValue(fields[i+1])
+		text := fmt.Sprint(value)
+		if text == "" || strings.ContainsAny(text, " \"=") {
+			text = strconv.Quote(text)
+		}
+		fmt.Fprintf(&line, " %v=%s", fields[i], text)
+	}
+
+	return line.String()
+}
+
+// The time, level and message come first, then the fields in order
+func formatLogJSON(level LogLevel, message string, fields []interface{}) string {
+	pairs := []interface{}{"time", time.Now().Format(time.RFC3339), "level", level.String(), "msg", message}
+	pairs = append(pairs, fields...)
+
+	var line strings.Builder
+	line.WriteString("{")
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key, _ := json.Marshal(fmt.Sprint(pairs[i]))
+		value, err := json.Marshal(logValue(pairs[i+1]))
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(pairs[i+1]))
+		}
+		if i > 0 {
+			line.WriteString(",")
+		}
+		fmt.Fprintf(&line, "%s:%s", key, value)
+	}
+	line.WriteString("}")
+
+	return line.String()
+}
+
+// Errors are logged as their message, they encode to {} otherwise, and
+// times in RFC 3339
+func logValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case error:
+		return value.Error()
+	case time.Time:
+		return value.Format(time.RFC3339)
+	}
+
+	return value
+}
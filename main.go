@@ -1,187 +1,728 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"sync"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
 )
 
-type GithubErrorResponse struct {
-	Message          string `json:"message"`
-	DocumentationURL string `json:"documentation_url"`
-	Status           string `json:"status"`
-}
+// How long fetched events stay in the cache, set with cache.ttl or
+// --cache-ttl
+var cacheTTL = 10 * time.Minute
 
-type GithubEvent struct {
-	Type  string `json:"type"`
-	Actor struct {
-		Login string `json:"login"`
-	} `json:"actor"`
-	Repo struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"repo"`
-	CreatedAt time.Time `json:"created_at"`
-}
+// $XDG_CACHE_HOME/github-activity, ~/.cache/github-activity when it isn't
+// set
+func cacheDir() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "."
+		}
+		base = filepath.Join(home, ".cache")
+	}
 
-type CacheItem struct {
-	Data      []GithubEvent
-	ExpiresAt time.Time
+	return filepath.Join(base, "github-activity")
 }
 
-var cache = make(map[string]CacheItem)
-var cacheMutex sync.Mutex
-var cacheFile = "cache.json"
-
 // Load cache from file
 func loadCache() {
-	cacheMutex.Lock()
-	defer cacheMutex.Unlock()
-
-	file, err := os.ReadFile(cacheFile)
+	err := cache.Load()
 	if err != nil {
 		if os.IsNotExist(err) {
 			// If file doesn't exist, skip loading
-			fmt.Println("Cache file not found, starting fresh")
+			logger.Debug("Cache file not found, starting fresh", "path", cache.Location())
+			return
+		}
+		if github.IsCorrupt(err) {
+			// Keep the file to look at, the next save writes a new one
+			backup := cache.Location() + ".corrupt"
+			if os.Rename(cache.Location(), backup) != nil {
+				logger.Warn("Cache file is corrupted, starting with an empty cache", "error", err)
+				return
+			}
+			logger.Warn("Cache file is corrupted, starting with an empty cache", "backup", backup, "error", err)
 			return
 		}
 
-		log.Fatalf("Error reading cache file: %v", err)
+		log.Fatalf("Error reading cache %s: %v", cache.Location(), err)
 	}
 
-	err = json.Unmarshal(file, &cache)
+	logger.Debug("Cache loaded", "path", cache.Location())
+}
+
+// Save cache to file, the events are already fetched so a failing save is
+// only reported
+func saveCache() {
+	err := cache.Save()
 	if err != nil {
-		log.Fatalf("Error parsing cache file: %v", err)
+		logger.Warn("Saving the cache failed, the events will be fetched again", "path", cache.Location(), "error", err)
+		return
 	}
 
-	fmt.Println("Cache loaded successfully")
+	logger.Debug("Cache saved", "path", cache.Location())
 }
 
-// Save cache to file
-func saveCache() {
-	cacheMutex.Lock()
-	defer cacheMutex.Unlock()
-
-	file, err := json.MarshalIndent(cache, "", " ")
+// The events stored in a cache item, nothing when they can't be decoded
+func cachedEvents(item github.CacheItem) []Event {
+	var events []Event
+	err := json.Unmarshal(item.Data, &events)
 	if err != nil {
-		log.Fatalf("Error serializing cache file: %v", err)
+		return nil
 	}
 
-	err = os.WriteFile(cacheFile, file, 0644)
-	if err != nil {
-		log.Fatalf("Error saving cache file: %v", err)
+	return events
+}
+
+func eventsCacheKey(provider Provider, username string) string {
+	// Organization, repository and received feeds are cached apart from the
+	// events of users
+	if github, ok := provider.(*githubProvider); ok && github.feed != FeedUser {
+		return fmt.Sprintf("%s-%s-events-%s", provider.Name(), github.feed, username)
 	}
 
-	fmt.Println("Cache saved successfully")
+	return fmt.Sprintf("%s-events-%s", provider.Name(), username)
 }
 
-func getGithubEvents(username string) ([]GithubEvent, error) {
-	cacheKey := fmt.Sprintf("github-events-%s", username)
+// Only return the events that weren't cached before the fetch, set with
+// --new-only
+var newOnly bool
+
+// Fetch without reading or writing the cache, set with --no-cache
+var noCache bool
+
+// Only read the cache, expired or not, and send no request, set with
+// --offline
+var offline bool
+
+func getEvents(provider Provider, username string) ([]Event, error) {
+	return streamEvents(provider, username, nil)
+}
+
+// Like getEvents but onPage, when not nil, gets the events as they arrive:
+// once with the cached events or page by page when fetching
+func streamEvents(provider Provider, username string, onPage func([]Event)) ([]Event, error) {
+	// New events are found by fetching, whether the cache expired or not
+	if newOnly || noCache {
+		return fetchEvents(provider, username, onPage)
+	}
+
+	cacheKey := eventsCacheKey(provider, username)
+	if offline {
+		return offlineEvents(cacheKey, username, onPage)
+	}
 
 	// Check existing cache
-	cacheMutex.Lock()
-	item, found := cache[cacheKey]
-	fmt.Printf("Cache found: %v, ExpiresAt: %v\n", found, item.ExpiresAt) // Debugging log
-	cacheMutex.Unlock()
+	item, found := cache.Get(cacheKey)
 
 	// Check if we have a valid cache hit
 	if found {
-		fmt.Println("Cache hit, checking expiration...")
-		if time.Now().Before(item.ExpiresAt) {
-			fmt.Println("Returning cached data")
-			return item.Data, nil
+		if !item.Expired() {
+			logger.Debug("Cache hit", "key", cacheKey, "expires_at", item.ExpiresAt)
+			metrics.CountCache(true)
+			events := applyMutes(cachedEvents(item))
+			if onPage != nil {
+				onPage(events)
+			}
+			return events, nil
 		}
-		fmt.Println("Cache expired, fetching fresh data")
+		logger.Debug("Cache expired, fetching fresh data", "key", cacheKey, "expired_at", item.ExpiresAt)
 	} else {
-		fmt.Println("Cache miss, fetching fresh data")
+		logger.Debug("Cache miss, fetching fresh data", "key", cacheKey)
 	}
 
-	// If not in cache or cache expired, make a request
-	githubUrl := fmt.Sprintf("https://api.github.com/users/%s/events", username)
-	resp, err := http.Get(githubUrl)
-	if err != nil {
-		return nil, err
+	// If not in cache or cache expired, ask the provider
+	metrics.CountCache(false)
+	return fetchEvents(provider, username, onPage)
+}
+
+// The cached events of a feed with --offline, expired or not
+func offlineEvents(cacheKey string, username string, onPage func([]Event)) ([]Event, error) {
+	item, found := cache.Get(cacheKey)
+	if !found {
+		return nil, fmt.Errorf("no cached events of %s, run without --offline to fetch them", username)
+	}
+	if item.Expired() {
+		logger.Debug("Cache expired, using it offline", "key", cacheKey, "expired_at", item.ExpiresAt)
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			return
-		}
-	}(resp.Body)
 
-	// Handling if the username is not found or error occurred
-	if resp.StatusCode != http.StatusOK {
-		var githubErrorResponse GithubErrorResponse
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
+	events := applyMutes(cachedEvents(item))
+	if onPage != nil {
+		onPage(events)
+	}
+	return events, nil
+}
+
+// Fetch fresh events from the provider and store them in the cache
+func refreshEvents(provider Provider, username string) ([]Event, error) {
+	return fetchEvents(provider, username, nil)
+}
+
+func fetchEvents(provider Provider, username string, onPage func([]Event)) ([]Event, error) {
+	cacheKey := eventsCacheKey(provider, username)
+	var item github.CacheItem
+	found := false
+	if !noCache {
+		item, found = cache.Get(cacheKey)
+	}
+	var seen map[string]bool
+	if newOnly {
+		seen = eventIDs(cachedEvents(item))
+	}
+
+	conditional, isConditional := provider.(ConditionalProvider)
+	if isConditional {
+		etag := ""
+		if found {
+			etag = item.ETag
 		}
+		conditional.SetETag(etag)
+	}
 
-		err = json.Unmarshal(body, &githubErrorResponse)
-		if err != nil {
-			return nil, err
+	events, err := streamNormalizedEvents(provider, username, func(page []Event) {
+		if onPage != nil {
+			fetchProgress.Clear()
+			onPage(applyMutes(unseenEvents(page, seen)))
 		}
+		fetchProgress.Page(provider.RateLimitInfo())
+	})
+	if isNotModified(err) {
+		// The cached events are still current, keep them for another TTL
+		logger.Debug("Not modified, cache extended", "key", cacheKey)
+		item.ExpiresAt = time.Now().Add(cacheTTL)
+		cache.Set(cacheKey, item)
+		saveCache()
 
-		return nil, errors.New(githubErrorResponse.Message)
+		events := applyMutes(unseenEvents(cachedEvents(item), seen))
+		if onPage != nil {
+			fetchProgress.Clear()
+			onPage(events)
+		}
+		return events, nil
 	}
-
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	var events []GithubEvent
-	err = json.Unmarshal(body, &events)
+	rateLimit := provider.RateLimitInfo()
+	if rateLimit.Limit > 0 {
+		logger.Debug("Rate limit", "remaining", rateLimit.Remaining, "limit", rateLimit.Limit, "reset", rateLimit.Reset)
+	}
+
+	// Store the response in cache until the TTL expires
+	data, err := json.Marshal(events)
 	if err != nil {
 		return nil, err
 	}
-
-	// Store the response in cache with a 10-minute expiration
-	cacheMutex.Lock()
-	cache[cacheKey] = CacheItem{
-		Data:      events,
-		ExpiresAt: time.Now().Add(10 * time.Minute),
+	item = github.CacheItem{
+		Data:      data,
+		ExpiresAt: time.Now().Add(cacheTTL),
+	}
+	if isConditional {
+		item.ETag = conditional.ETag()
 	}
-	fmt.Printf("Cache updated for key: %s, ExpiresAt: %v\n", cacheKey, cache[cacheKey].ExpiresAt) // Debugging log
-	cacheMutex.Unlock()
+	storeEvents(cacheKey, events)
+	if noCache {
+		return applyMutes(events), nil
+	}
+	cache.Set(cacheKey, item)
+	logger.Debug("Cache updated", "key", cacheKey, "expires_at", item.ExpiresAt)
 
 	// Save the cache to a file
 	saveCache()
 
-	fmt.Println("Returning fresh data")
-	return events, nil
+	return applyMutes(unseenEvents(events, seen)), nil
+}
+
+func eventIDs(events []Event) map[string]bool {
+	ids := make(map[string]bool, len(events))
+	for _, event := range events {
+		ids[storedEventID(event)] = true
+	}
+
+	return ids
+}
+
+// The events that aren't in seen, all of them when seen is nil
+func unseenEvents(events []Event, seen map[string]bool) []Event {
+	if seen == nil {
+		return events
+	}
+
+	var unseen []Event
+	for _, event := range events {
+		if !seen[storedEventID(event)] {
+			unseen = append(unseen, event)
+		}
+	}
+
+	return unseen
+}
+
+// Client for every API request, --timeout sets its timeout. Sharing it
+// shares its connections, kept alive between the pages and the users.
+var httpClient = &http.Client{Transport: github.NewTransport()}
+
+// Refuses every request with --offline, so the requests that don't read the
+// cache fail instead of going out
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("not requesting %s with --offline", req.URL.Host)
+}
+
+// Sleep until the rate limit resets and retry instead of failing, set with
+// --wait
+var waitForRateLimit bool
+
+// How many times a request failing with a 5xx, a secondary rate limit or a
+// reset connection is sent again, set with --retries
+var maxRetries = 3
+
+// Register --timeout, --deadline, --wait and --retries on a command that sends
+// requests, the returned function resolves them like the other settings and
+// applies them. Once the deadline is over the requests are canceled like on
+// Ctrl+C, the command saves what it fetched and exits with an error.
+func addTimeoutFlags(flags *flag.FlagSet) func(settings *settingsResolver) {
+	timeout := flags.Duration("timeout", 0, "time limit for each request, e.g. 10s (default no limit)")
+	deadline := flags.Duration("deadline", 0, "time limit for the whole command, e.g. 2m (default no limit)")
+	wait := flags.Bool("wait", false, "when the rate limit is exceeded, sleep until it resets and retry")
+	retries := flags.Int("retries", maxRetries, "times a request failing with a 5xx, a secondary rate limit or a reset connection is retried, with a growing delay")
+
+	return func(settings *settingsResolver) {
+		for _, err := range []error{
+			settings.Resolve("timeout", "GITHUB_ACTIVITY_TIMEOUT", "defaults.timeout"),
+			settings.Resolve("deadline", "GITHUB_ACTIVITY_DEADLINE", "defaults.deadline"),
+			settings.Resolve("retries", "GITHUB_ACTIVITY_RETRIES", "defaults.retries"),
+		} {
+			if err != nil {
+				log.Fatalf("Error resolving settings: %v", err)
+			}
+		}
+		httpClient.Timeout = *timeout
+		waitForRateLimit = *wait
+		maxRetries = *retries
+		if *deadline > 0 {
+			limit := *deadline
+			time.AfterFunc(limit, func() {
+				fetchProgress.Stop()
+				logger.Error("Deadline exceeded, stopping", "deadline", limit.String())
+				interrupt(context.DeadlineExceeded)
+			})
+		}
+	}
+}
+
+// Error returned for a non 200 response of any forge
+type HTTPError = github.Error
+
+func isNotFound(err error) bool {
+	return github.HasStatus(err, http.StatusNotFound)
+}
+
+func isNotModified(err error) bool {
+	return github.HasStatus(err, http.StatusNotModified)
+}
+
+func isForbidden(err error) bool {
+	return github.HasStatus(err, http.StatusForbidden)
+}
+
+// A Github client for --api-url sending requests with the client of
+// --timeout, sleeping for an exceeded rate limit with --wait
+func newGithubClient(token string) *github.Client {
+	client := github.NewClient(httpClient, githubAPIURL, token)
+	client.RetryRateLimited = waitForRateLimit
+	client.Context = interrupted
+	client.Retries = maxRetries
+	client.OnRetry = func(err error, attempt int, wait time.Duration) {
+		logger.Warn("Request failed, retrying", "attempt", fmt.Sprintf("%d/%d", attempt, maxRetries), "wait", wait.Round(100*time.Millisecond).String(), "error", err)
+	}
+	client.OnRateLimited = func(err *github.Error, wait time.Duration) {
+		message := err.Message
+		if err.SecondaryRateLimit {
+			message = "Secondary rate limit exceeded"
+		}
+		logger.Warn(message+", waiting until it resets", "wait", wait.Round(time.Second).String())
+	}
+
+	return client
+}
+
+// Warn when results of organizations were left out because the token isn't
+// authorized for their SAML SSO
+func warnPartialResults(header http.Header) {
+	if state, organizations := github.ParseSSOHeader(header); state == "partial-results" {
+		logger.Warn("Results of organizations are missing, the token is not authorized for their SAML SSO (see https://github.com/settings/tokens)", "organizations", organizations)
+	}
+}
+
+// Send the request and decode the JSON response into v, the response headers
+// are returned so callers can inspect rate limits. With --wait a request
+// that exceeded the rate limit is sent again once it resets.
+func doJSONRequest(req *http.Request, v interface{}) (http.Header, error) {
+	header, err := newGithubClient("").Do(req, v)
+	if err == nil {
+		warnPartialResults(header)
+	}
+
+	return header, err
+}
+
+// Token sent by getGithubJSON, the default command replaces it with the
+// resolved --token when fetching from Github
+var githubToken = os.Getenv("GITHUB_TOKEN")
+
+// The Github API, the one of a Github Enterprise Server with --api-url,
+// GITHUB_API_URL or github.api_url
+var githubAPIURL = github.DefaultBaseURL
+
+// The --api-url flag, it sets githubAPIURL to the normalized URL
+type githubAPIURLFlag struct{}
+
+func (githubAPIURLFlag) String() string {
+	return githubAPIURL
+}
+
+func (githubAPIURLFlag) Set(value string) error {
+	return setGithubAPIURL(value)
+}
+
+// Send a GET request for a path of the Github API, or a URL, and decode the
+// JSON response into v
+func getGithubJSON(githubUrl string, v interface{}) error {
+	header, err := newGithubClient(githubToken).Get(githubUrl, v)
+	if err == nil {
+		warnPartialResults(header)
+	}
+
+	return err
+}
+
+// A flag that can be repeated or given a comma-separated list
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// Print v as indented JSON to stdout
+func printJSON(v interface{}) error {
+	return writeJSON(os.Stdout, v, true)
+}
+
+// Write v as JSON, on a single line unless pretty is set
+func writeJSON(w io.Writer, v interface{}, pretty bool) error {
+	encoder := json.NewEncoder(w)
+	if pretty {
+		encoder.SetIndent("", " ")
+	}
+	return encoder.Encode(v)
+}
+
+// A subcommand, Usage and Summary are shown by help and --help
+type Command struct {
+	Usage   string
+	Summary string
+	Run     func(args []string)
+	// Hidden commands are used by scripts and left out of help
+	Hidden bool
+}
+
+// Subcommands, any other first argument is a username or a flag of fetch so
+// the original usage keeps working
+var commands map[string]Command
+
+func init() {
+	// Assigned in init because help and completion list the commands
+	commands = map[string]Command{
+		"fetch": {
+			Usage:   "fetch [--provider github|gitlab|gitea|bitbucket] [--token token] [--base-url url] [--preset name] [--type type] [--since 7d] [--until 2024-01-31] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--format text|json|ndjson|csv|markdown|html|ics] [--pretty] [--absolute] [--tz zone] [--pages n] [--limit n] [--no-progress] [--verbose] [--timeout 10s] [--deadline 2m] [--wait] [--retries 3] [-f [--interval 1m] [--notify] [--notify-desktop]] [--verify-commits] [--only-unverified] [--enrich] [--new-only] [--open n] [--template text|file] [--explain-config] [--merge] [--group-by repo|type|day] [--sort asc|desc] [--concurrency 4] [--received] [--stdin | <username>...]",
+			Summary: "Print the recent events of one or more users, \"fetch\" can be left out",
+			Run:     runFetch,
+		},
+		"watch": {
+			Usage:   "watch [--interval 1m] [--preset name] [--type type] [--provider name] <username>",
+			Summary: "Print the events of a user, then the new ones as they arrive, like tail -f",
+			Run:     runWatch,
+		},
+		"org": {
+			Usage:   "org [--preset name] [--type type] [--since 7d] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--format text|json|ndjson|csv|markdown|html|ics] [--pages n] [--limit n] [--merge] [--group-by repo|type|day] [--sort asc|desc] [-f [--interval 1m]] <org>...",
+			Summary: "Print the recent public events of Github organizations, like fetch does for users",
+			Run:     runOrg,
+		},
+		"repo": {
+			Usage:   "repo [--preset name] [--type type] [--since 7d] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--format text|json|ndjson|csv|markdown|html|ics] [--pages n] [--limit n] [--merge] [--group-by repo|type|day] [--sort asc|desc] [-f [--interval 1m]] <owner/name>...",
+			Summary: "Print the recent events of Github repositories, like fetch does for users",
+			Run:     runRepo,
+		},
+		"summary": {
+			Usage:   "summary [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 7d] [--until 2024-01-31] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--format text|json|ics] [--store file|sqlite] [--timeout 10s] [--deadline 2m] [--retries 3] <username>",
+			Summary: "Count the recent events of a user per type and repository",
+			Run:     runSummary,
+		},
+		"graph": {
+			Usage:   "graph [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 30d] [--until 2024-01-31] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--pages n] [--color] [--source events|graphql] [--output chart.svg] [--chart heatmap|bars] [--store file|sqlite] [--timeout 10s] [--deadline 2m] [--retries 3] <username>",
+			Summary: "Draw the events of a user as a calendar heatmap of the days",
+			Run:     runGraph,
+		},
+		"sync": {
+			Usage:   "sync [--provider name] [--token token] [--base-url url] [--pages n] [--store file|sqlite] [--store-path path] [--notify] [--timeout 10s] [--deadline 2m] [--retries 3] <username>...",
+			Summary: "Store the events of users newer than the stored ones, for cron",
+			Run:     runSync,
+		},
+		"daemon": {
+			Usage:   "daemon [--provider name] [--token token] [--base-url url] [--pages n] [--schedule 15m|\"*/15 * * * *\"] [--org name] [--store file|sqlite] [--store-path path] [--notify] [--notify-desktop] [--timeout 10s] [--deadline 2m] [--retries 3] [username...]",
+			Summary: "Keep running and sync users and organizations on a schedule, posting the new events",
+			Run:     runDaemon,
+		},
+		"serve": {
+			Usage:   "serve [--listen 127.0.0.1:8080] [--provider name] [--token token] [--base-url url] [--pages n] [--cache-ttl 10m] [--store file|sqlite] [--timeout 10s] [--deadline 2m] [--retries 3]",
+			Summary: "Serve the events and summaries of users as JSON over HTTP",
+			Run:     runServe,
+		},
+		"notify": {
+			Usage:   "notify list | test [name]",
+			Summary: "List the webhooks new events are posted to and post a sample event",
+			Run:     runNotify,
+		},
+		"cache": {
+			Usage:   "cache list | stats | clear [--expired] [username] | path [--format text|json]",
+			Summary: "Inspect the cached events and clear them, of every user, of one or only the expired ones",
+			Run:     runCache,
+		},
+		"trending": {
+			Usage:   "trending [--language go] [--since daily|weekly|monthly] [--format text|json] [--token token] [--timeout 10s] [--deadline 2m] [--wait] [--retries 3]",
+			Summary: "Show trending repositories",
+			Run:     runTrending,
+		},
+		"discover": {
+			Usage:   "discover --topic cli [--language go] [--days 30] [--limit 20] [--format text|json] [--token token] [--timeout 10s] [--deadline 2m] [--wait] [--retries 3]",
+			Summary: "Discover actively maintained repositories by topic",
+			Run:     runDiscover,
+		},
+		"feed": {
+			Usage:   "feed --account provider:username[@base-url] [--account ...] [--preset name] [--type type] [--since 7d] [--until 2024-01-31] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--limit n] [--group-by repo|type|day] [--sort asc|desc] [--format text|json] [--absolute] [--tz zone] [--no-progress] [--timeout 10s] [--deadline 2m] [--wait] [--retries 3]",
+			Summary: "Merge the activity of several accounts across providers",
+			Run:     runFeed,
+		},
+		"dashboard": {
+			Usage:   "dashboard [--account provider:username[@base-url] ...] [--refresh 5m] [--tz zone] [--timeout 10s] [--deadline 2m] [--retries 3]",
+			Summary: "Terminal dashboard with a pane per account",
+			Run:     runDashboard,
+		},
+		"tui": {
+			Usage:   "tui [--account provider:username[@base-url] ...] [--refresh 5m] [--tz zone] [--timeout 10s] [--deadline 2m] [--retries 3]",
+			Summary: "Same as dashboard",
+			Run:     runDashboard,
+		},
+		"digest": {
+			Usage:   "digest [--provider name] [--token token] [--base-url url] [--preset name] [--since 7d] [--until 2024-01-31] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--pages 3] [--format markdown|html] [--no-progress] [--notify] [--timeout 10s] [--deadline 2m] [--retries 3] <username or alias>",
+			Summary: "Summarize a week of work of a user or team as Markdown or HTML, or post it to webhooks",
+			Run:     runDigest,
+		},
+		"streak": {
+			Usage:   "streak [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 1y] [--until 2024-01-31] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--pages n] [--gap 7] [--source events|graphql] [--format text|json] [--store none|file|sqlite] [--timeout 10s] [--deadline 2m] [--retries 3] <username>",
+			Summary: "Count the days in a row with activity and list the gaps without any",
+			Run:     runStreak,
+		},
+		"badge": {
+			Usage:   "badge [--provider name] [--token token] [--base-url url] [--kind events|streak] [--label text] [--since 7d] [--type type] [--pages n] [--output badge.svg] [--store none|file|sqlite] [--timeout 10s] [--deadline 2m] [--retries 3] <username>",
+			Summary: "Print an SVG badge of the recent events or the streak of a user",
+			Run:     runBadge,
+		},
+		"team": {
+			Usage:   "team [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 7d] [--until 2024-01-31] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--limit n] [--format text|json] [--concurrency 4] [--no-progress] [--tz zone] [--timeout 10s] [--deadline 2m] [--retries 3] <alias>",
+			Summary: "Merge the activity of the members of a list alias, with a team summary",
+			Run:     runTeam,
+		},
+		"compare": {
+			Usage:   "compare [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 7d] [--until 2024-01-31] [--include-repo glob] [--exclude-bots] [--exclude-type type] [--exclude-repo glob] [--format text|json] [--timeout 10s] [--deadline 2m] [--retries 3] <user1> <user2>",
+			Summary: "Compare the activity of two users side by side",
+			Run:     runCompare,
+		},
+		"show": {
+			Usage:   "show [--timeout 10s] [--deadline 2m] [--retries 3] <event-id>",
+			Summary: "Show the details of a cached event",
+			Run:     runShow,
+		},
+		"bookmark": {
+			Usage:   "bookmark <event-id>",
+			Summary: "Bookmark a cached event, or remove its bookmark",
+			Run:     runBookmark,
+		},
+		"bookmarks": {
+			Usage:   "bookmarks [--format text|json] [--absolute] [--tz zone]",
+			Summary: "List the bookmarked events",
+			Run:     runBookmarks,
+		},
+		"annotate": {
+			Usage:   "annotate [--clear] <event-id|YYYY-MM-DD> \"note\"",
+			Summary: "Attach a note to an event or a day",
+			Run:     runAnnotate,
+		},
+		"mute": {
+			Usage:   "mute [repo owner/name | type EventType]",
+			Summary: "Hide a repository or an event type everywhere, list the mutes without arguments",
+			Run:     runMute,
+		},
+		"unmute": {
+			Usage:   "unmute repo owner/name | type EventType",
+			Summary: "Undo a mute",
+			Run:     runUnmute,
+		},
+		"config": {
+			Usage:   "config init [--yaml] [--force] | get <key> | set <key> <value...> | unset <key>",
+			Summary: "Create, read and change the config file",
+			Run:     runConfig,
+		},
+		"auth": {
+			Usage:   "auth status [--token token] [--format text|json] [--timeout 10s] [--deadline 2m] [--retries 3]",
+			Summary: "Check the Github token, same as whoami",
+			Run:     runAuth,
+		},
+		"whoami": {
+			Usage:   "whoami [--token token] [--format text|json] [--timeout 10s] [--deadline 2m] [--retries 3]",
+			Summary: "Show who the Github token belongs to, its scopes, expiry and rate limit",
+			Run:     runWhoami,
+		},
+		"audit": {
+			Usage:   "audit <org> [--enterprise] [--phrase query] [--preset name] [--format text|ndjson] [--pages n] [--timeout 10s] [--deadline 2m] [--retries 3]",
+			Summary: "List the audit log of an organization or enterprise",
+			Run:     runAudit,
+		},
+		"contributions": {
+			Usage:   "contributions <username> [--year 2024] [--format text|json] [--timeout 10s] [--deadline 2m] [--retries 3]",
+			Summary: "Exact yearly contribution counts and calendar",
+			Run:     runContributions,
+		},
+		"snapshot": {
+			Usage:   "snapshot save [--provider name] [--token token] [--base-url url] [--timeout 10s] [--deadline 2m] [--retries 3] <name> <username> | diff <a> <b> [--format text|json] | list",
+			Summary: "Save the activity of users and compare two snapshots",
+			Run:     runSnapshot,
+		},
+		"prune": {
+			Usage:   "prune [--cache 7d] [--archive 2y] [--snapshots 1y] [--events 1y] [--store file|sqlite] [--store-path path]",
+			Summary: "Remove old cache entries, bookmarks, notes, snapshots and stored events",
+			Run:     runPrune,
+		},
+		"completion": {
+			Usage:   "completion bash|zsh|fish|powershell",
+			Summary: "Print the shell completion script",
+			Run:     runCompletion,
+		},
+		"help": {
+			Usage:   "help [command]",
+			Summary: "Show the commands, or the usage and flags of one",
+			Run:     runHelp,
+		},
+		"__complete": {
+			Usage:  "__complete users|types <prefix>",
+			Run:    runComplete,
+			Hidden: true,
+		},
+	}
+}
+
+// A flag set whose --help prints the usage and summary of the command
+// before its flags
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.BoolVar(&logger.Verbose, "verbose", logger.Verbose, "also write info and debug diagnostics on stderr, like the rate limit and cache hits")
+	flags.BoolVar(&logger.Quiet, "quiet", logger.Quiet, "only write errors on stderr, no warnings")
+	flags.Var(githubAPIURLFlag{}, "api-url", "Github API URL, e.g. https://github.mycorp.com/api/v3 for a Github Enterprise Server")
+	flags.Func("log-format", "format of the diagnostics on stderr: text or json (default text)", func(value string) error {
+		if value != "text" && value != "json" {
+			return fmt.Errorf("expected text or json")
+		}
+		logger.JSON = value == "json"
+		return nil
+	})
+	// The default keeps defaults.color of the config file
+	flags.BoolVar(&noColor, "no-color", noColor, "don't color the text output, also set with NO_COLOR")
+	flags.BoolVar(&noPager, "no-pager", noPager, "don't page long output through $PAGER")
+	flags.Usage = func() {
+		command := commands[name]
+		fmt.Fprintf(flags.Output(), "Usage: go run . %s\n", command.Usage)
+		if command.Summary != "" {
+			fmt.Fprintf(flags.Output(), "\n%s\n", command.Summary)
+		}
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) {
+			hasFlags = true
+		})
+		if hasFlags {
+			fmt.Fprintln(flags.Output(), "\nFlags:")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run . <command> [arguments]")
+	fmt.Println("       go run . <username>, same as fetch <username>")
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, name := range commandNames() {
+		fmt.Printf("  %-14s %s\n", name, commands[name].Summary)
+	}
+	fmt.Println()
+	fmt.Println("Run go run . help <command> or go run . <command> --help for the flags of a command.")
+}
+
+func runHelp(args []string) {
+	if len(args) == 0 {
+		printUsage()
+		return
+	}
+
+	command, ok := commands[args[0]]
+	if !ok || command.Hidden {
+		log.Fatalf("Unknown command %q, run help to list the commands", args[0])
+	}
+	command.Run([]string{"--help"})
 }
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run main.go [command: github username]")
+		printUsage()
 		return
 	}
 
-	loadCache()
+	// Apply the settings and the [retention] policy of the config file before
+	// every command
+	applyGlobalSettings()
+	autoPrune()
 
-	githubUsername := os.Args[1]
-	events, err := getGithubEvents(githubUsername)
-	if err != nil {
-		log.Fatalf("Error fetching events: %v", err)
+	if command, ok := commands[os.Args[1]]; ok {
+		command.Run(os.Args[2:])
 		return
 	}
-
-	for _, event := range events {
-		fmt.Printf("Type: %s\n", event.Type)
-		fmt.Printf("Actor Login: %s\n", event.Actor.Login)
-		fmt.Printf("Repo Name: %s\n", event.Repo.Name)
-		fmt.Printf("Repo URL: %s\n", event.Repo.URL)
-		fmt.Printf("Created At: %s\n", event.CreatedAt.Format("2006-01-02 15:04:05"))
-		fmt.Println("----------------------")
+	if os.Args[1] == "-h" || os.Args[1] == "--help" {
+		printUsage()
+		return
 	}
 
-	// Save the cache before exiting
-	saveCache()
+	runFetch(os.Args[1:])
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`", "<", `\<`)
+
+// Write the events as a Markdown report for standup docs and READMEs: a
+// section per repository, most recently active first, with a dated bullet
+// per event linking to it, its commits and notes, then the notes of the days
+func writeMarkdownReport(w io.Writer, names []string, events []Event) {
+	sorted := make([]Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	fmt.Fprintf(w, "# Activity of %s\n\n", strings.Join(names, ", "))
+	if len(sorted) == 0 {
+		fmt.Fprintln(w, "No events.")
+		return
+	}
+	first := sorted[len(sorted)-1].CreatedAt.Local().Format(dayLayout)
+	last := sorted[0].CreatedAt.Local().Format(dayLayout)
+	fmt.Fprintf(w, "%d %s from %s to %s\n", len(sorted), plural(len(sorted), "event"), first, last)
+
+	var repos []string
+	byRepo := make(map[string][]Event)
+	actors := make(map[string]bool)
+	for _, event := range sorted {
+		if _, found := byRepo[event.Repo.Name]; !found {
+			repos = append(repos, event.Repo.Name)
+		}
+		byRepo[event.Repo.Name] = append(byRepo[event.Repo.Name], event)
+		actors[event.Actor.Login] = true
+	}
+
+	for _, repo := range repos {
+		repoEvents := byRepo[repo]
+		fmt.Fprintf(w, "\n## [%s](%s)\n\n", markdownEscaper.Replace(repo), repoHTMLURL(repoEvents[0]))
+		for _, event := range repoEvents {
+			// The section already names the repository
+			sentence := strings.TrimSuffix(describeEvent(event), " in "+event.Repo.Name)
+			line := fmt.Sprintf("- **%s** [%s](%s)", event.CreatedAt.Local().Format(dayLayout), markdownEscaper.Replace(sentence), eventHTMLURL(event))
+			if event.Target.Title != "" && event.Action != ActionForked && event.Action != ActionJoined {
+				line += ": " + markdownEscaper.Replace(event.Target.Title)
+			}
+			if len(actors) > 1 {
+				line += " by " + markdownEscaper.Replace(event.Actor.Login)
+			}
+			fmt.Fprintln(w, line)
+
+			for _, commit := range event.Commits {
+				message := strings.SplitN(commit.Message, "\n", 2)[0]
+				fmt.Fprintf(w, "  - `%s` %s\n", shortSHA(commit.SHA), markdownEscaper.Replace(message))
+			}
+			for _, note := range eventNotes(event.ID) {
+				fmt.Fprintf(w, "  - _Note:_ %s\n", markdownEscaper.Replace(note.Text))
+			}
+		}
+	}
+
+	var notes []string
+	seen := make(map[string]bool)
+	for _, event := range sorted {
+		day := event.CreatedAt.Local().Format(dayLayout)
+		if seen[day] {
+			continue
+		}
+		seen[day] = true
+		for _, note := range dayNotes(day) {
+			notes = append(notes, fmt.Sprintf("- **%s** %s", day, markdownEscaper.Replace(note.Text)))
+		}
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(w, "\n## Notes\n\n%s\n", strings.Join(notes, "\n"))
+	}
+}
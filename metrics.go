@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Counters of serve, exposed at /metrics in the Prometheus text format
+type serveMetrics struct {
+	mutex sync.Mutex
+	// Events seen per user and type, every event is counted once
+	events     map[[2]string]int
+	seenEvents map[string]bool
+	cacheHits  int
+	cacheMiss  int
+	// API requests per host and status, 0 for requests that got no response
+	requests map[[2]string]int
+	// Rate limit of the last response per provider
+	rateLimits map[string]RateLimit
+}
+
+// The metrics of serve, nil for the other commands
+var metrics *serveMetrics
+
+func newServeMetrics() *serveMetrics {
+	return &serveMetrics{
+		events:     make(map[[2]string]int),
+		seenEvents: make(map[string]bool),
+		requests:   make(map[[2]string]int),
+		rateLimits: make(map[string]RateLimit),
+	}
+}
+
+func (m *serveMetrics) CountEvents(username string, events []Event) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, event := range events {
+		id := username + "/" + storedEventID(event)
+		if m.seenEvents[id] {
+			continue
+		}
+		m.seenEvents[id] = true
+		m.events[[2]string{username, event.Type}]++
+	}
+}
+
+func (m *serveMetrics) CountCache(hit bool) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if hit {
+		m.cacheHits++
+	} else {
+		m.cacheMiss++
+	}
+}
+
+func (m *serveMetrics) CountRequest(host string, status int) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.requests[[2]string{host, strconv.Itoa(status)}]++
+}
+
+func (m *serveMetrics) SetRateLimit(provider string, rateLimit RateLimit) {
+	if m == nil || rateLimit.Limit == 0 {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.rateLimits[provider] = rateLimit
+}
+
+// Write the metrics in the Prometheus text exposition format
+func (m *serveMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var b strings.Builder
+	writeMetricHeader(&b, "github_activity_events_total", "counter", "Events seen per user and type")
+	for _, key := range sortedKeys(m.events) {
+		fmt.Fprintf(&b, "github_activity_events_total{user=%s,type=%s} %d\n", metricLabel(key[0]), metricLabel(key[1]), m.events[key])
+	}
+	writeMetricHeader(&b, "github_activity_cache_hits_total", "counter", "Events requests answered from the cache")
+	fmt.Fprintf(&b, "github_activity_cache_hits_total %d\n", m.cacheHits)
+	writeMetricHeader(&b, "github_activity_cache_misses_total", "counter", "Events requests that fetched from the provider")
+	fmt.Fprintf(&b, "github_activity_cache_misses_total %d\n", m.cacheMiss)
+	writeMetricHeader(&b, "github_activity_api_requests_total", "counter", "API requests per host and status, 0 when no response came")
+	for _, key := range sortedKeys(m.requests) {
+		fmt.Fprintf(&b, "github_activity_api_requests_total{host=%s,status=%s} %d\n", metricLabel(key[0]), metricLabel(key[1]), m.requests[key])
+	}
+	providers := make([]string, 0, len(m.rateLimits))
+	for provider := range m.rateLimits {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	writeMetricHeader(&b, "github_activity_rate_limit_remaining", "gauge", "Requests left in the rate limit window, as of the last response")
+	for _, provider := range providers {
+		fmt.Fprintf(&b, "github_activity_rate_limit_remaining{provider=%s} %d\n", metricLabel(provider), m.rateLimits[provider].Remaining)
+	}
+	writeMetricHeader(&b, "github_activity_rate_limit_limit", "gauge", "Requests allowed in the rate limit window")
+	for _, provider := range providers {
+		fmt.Fprintf(&b, "github_activity_rate_limit_limit{provider=%s} %d\n", metricLabel(provider), m.rateLimits[provider].Limit)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (m *serveMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, err := m.WriteTo(w)
+	if err != nil {
+		logger.Warn("Writing response failed", "error", err)
+	}
+}
+
+func writeMetricHeader(b *strings.Builder, name string, kind string, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// A quoted label value, Go quoting escapes backslashes, quotes and newlines
+// like the exposition format
+func metricLabel(value string) string {
+	return strconv.Quote(value)
+}
+
+func sortedKeys(counts map[[2]string]int) [][2]string {
+	keys := make([][2]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	return keys
+}
+
+// Count every request the API clients send, wrapped around the transport of
+// httpClient by serve
+type countingTransport struct {
+	next http.RoundTripper
+}
+
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	metrics.CountRequest(req.URL.Host, status)
+
+	return resp, err
+}
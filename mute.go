@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Repositories and event types hidden from every feed. Repositories may be
+// glob patterns like owner/*.
+type Mutes struct {
+	Repos []string `json:"repos"`
+	Types []string `json:"types"`
+}
+
+func isMuted(event Event) bool {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	for _, eventType := range archive.Mutes.Types {
+		if event.Type == eventType {
+			return true
+		}
+	}
+
+	return matchesRepo(archive.Mutes.Repos, event.Repo.Name)
+}
+
+// Drop the muted events, the cache keeps them so unmuting brings them back
+func applyMutes(events []Event) []Event {
+	filtered := make([]Event, 0, len(events))
+	for _, event := range events {
+		if !isMuted(event) {
+			filtered = append(filtered, event)
+		}
+	}
+
+	return filtered
+}
+
+// Add or remove a value from a muted list, returns false if nothing changed
+func updateMuteList(list *[]string, value string, mute bool) bool {
+	for i, existing := range *list {
+		if existing == value {
+			if !mute {
+				*list = append((*list)[:i], (*list)[i+1:]...)
+			}
+			return !mute
+		}
+	}
+
+	if mute {
+		*list = append(*list, value)
+		sort.Strings(*list)
+	}
+	return mute
+}
+
+func runMute(args []string) {
+	flags := newFlagSet("mute")
+	_ = flags.Parse(args)
+	changeMutes(flags.Args(), true)
+}
+
+func runUnmute(args []string) {
+	flags := newFlagSet("unmute")
+	_ = flags.Parse(args)
+	changeMutes(flags.Args(), false)
+}
+
+func changeMutes(args []string, mute bool) {
+	err := loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	// Without arguments mute lists what is muted
+	if len(args) == 0 && mute {
+		for _, repo := range archive.Mutes.Repos {
+			fmt.Printf("repo %s\n", repo)
+		}
+		for _, eventType := range archive.Mutes.Types {
+			fmt.Printf("type %s\n", eventType)
+		}
+		return
+	}
+
+	if len(args) != 2 {
+		log.Fatalf("Usage: mute|unmute repo owner/name or mute|unmute type EventType")
+	}
+
+	archiveMutex.Lock()
+	var changed bool
+	switch args[0] {
+	case "repo":
+		if err := validateRepoPatterns([]string{args[1]}); err != nil {
+			archiveMutex.Unlock()
+			log.Fatalf("Error muting: %v", err)
+		}
+		changed = updateMuteList(&archive.Mutes.Repos, args[1], mute)
+	case "type":
+		changed = updateMuteList(&archive.Mutes.Types, args[1], mute)
+	default:
+		archiveMutex.Unlock()
+		log.Fatalf("Unknown mute kind %q, expected repo or type", args[0])
+	}
+	archiveMutex.Unlock()
+
+	switch {
+	case !changed && mute:
+		fmt.Printf("%s %s is already muted\n", args[0], args[1])
+	case !changed:
+		fmt.Printf("%s %s is not muted\n", args[0], args[1])
+	case mute:
+		fmt.Printf("Muted %s %s\n", args[0], args[1])
+	default:
+		fmt.Printf("Unmuted %s %s\n", args[0], args[1])
+	}
+
+	err = saveArchive()
+	if err != nil {
+		log.Fatalf("Error saving archive: %v", err)
+	}
+}
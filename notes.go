@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+type Note struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const dayLayout = "2006-01-02"
+
+// Attach a note to an event ID or to a day written as YYYY-MM-DD
+func addNote(target string, text string) {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	note := Note{Text: text, CreatedAt: time.Now()}
+	if isDay(target) {
+		archive.DayNotes[target] = append(archive.DayNotes[target], note)
+		return
+	}
+	archive.EventNotes[target] = append(archive.EventNotes[target], note)
+}
+
+func clearNotes(target string) {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	delete(archive.DayNotes, target)
+	delete(archive.EventNotes, target)
+}
+
+func eventNotes(id string) []Note {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	return archive.EventNotes[id]
+}
+
+func dayNotes(day string) []Note {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	return archive.DayNotes[day]
+}
+
+func isDay(target string) bool {
+	_, err := time.Parse(dayLayout, target)
+	return err == nil
+}
+
+func runAnnotate(args []string) {
+	flags := newFlagSet("annotate")
+	clear := flags.Bool("clear", false, "remove the notes of the event or day")
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 || (!*clear && flags.NArg() < 2) {
+		log.Fatalf("Usage: annotate <event-id|YYYY-MM-DD> \"note\" or annotate --clear <event-id|YYYY-MM-DD>")
+	}
+
+	err := loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	target := flags.Arg(0)
+	if *clear {
+		clearNotes(target)
+		fmt.Printf("Removed the notes of %s\n", target)
+	} else {
+		addNote(target, strings.Join(flags.Args()[1:], " "))
+		fmt.Printf("Added note to %s\n", target)
+	}
+
+	err = saveArchive()
+	if err != nil {
+		log.Fatalf("Error saving archive: %v", err)
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// A webhook the new events of follow mode and sync are posted to, defined in
+// the config file as
+//
+//	[notify.team]
+//	url = "https://hooks.slack.com/services/..."
+//	kind = "slack"
+//	types = ["PushEvent", "PullRequestEvent"]
+//	repos = ["mycorp/*"]
+//	template = "{{.Actor}}: {{.Sentence}}"
+type Notifier struct {
+	Name string
+	URL  string
+	// slack or discord, guessed from the URL when not set
+	Kind   string
+	Filter EventFilter
+	// Renders the text of the message, the sentence of the event by default
+	Template *template.Template
+}
+
+// What a notification template can use
+type NotifyMessage struct {
+	Event    Event
+	Sentence string
+	URL      string
+	Actor    string
+	Repo     string
+	Type     string
+}
+
+const defaultNotifyTemplate = "{{.Sentence}}"
+
+// The notifiers of --notify, nil when new events aren't posted
+var notifiers []*Notifier
+
+func loadNotifiers(config Config) ([]*Notifier, error) {
+	var loaded []*Notifier
+	for _, name := range configSectionNames(config, "notify") {
+		prefix := "notify." + name + "."
+		notifier := &Notifier{
+			Name: name,
+			URL:  config.String(prefix+"url", ""),
+			Kind: config.String(prefix+"kind", ""),
+			Filter: EventFilter{
+				Repos: config.Strings(prefix + "repos"),
+				Types: config.Strings(prefix + "types"),
+			},
+		}
+		if notifier.URL == "" {
+			return nil, fmt.Errorf("notify %s: the url is missing", name)
+		}
+		if notifier.Kind == "" {
+			notifier.Kind = webhookKind(notifier.URL)
+		}
+		if notifier.Kind != "slack" && notifier.Kind != "discord" {
+			return nil, fmt.Errorf("notify %s: set the kind to slack or discord", name)
+		}
+		if err := validateRepoPatterns(notifier.Filter.Repos); err != nil {
+			return nil, fmt.Errorf("notify %s: %v", name, err)
+		}
+
+		var err error
+		notifier.Template, err = template.New(name).Parse(config.String(prefix+"template", defaultNotifyTemplate))
+		if err != nil {
+			return nil, fmt.Errorf("notify %s: %v", name, err)
+		}
+		loaded = append(loaded, notifier)
+	}
+
+	return loaded, nil
+}
+
+// Guess the kind of a webhook from its URL, empty when it can't
+func webhookKind(webhookURL string) string {
+	switch {
+	case strings.Contains(webhookURL, "hooks.slack.com/"):
+		return "slack"
+	case strings.Contains(webhookURL, "discord.com/api/webhooks/"), strings.Contains(webhookURL, "discordapp.com/api/webhooks/"):
+		return "discord"
+	default:
+		return ""
+	}
+}
+
+// Post the events every notifier is interested in, oldest first. A webhook
+// that fails is reported and the others still get the events.
+func notifyEvents(events []Event) {
+	if len(notifiers) == 0 || len(events) == 0 {
+		return
+	}
+
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	for _, notifier := range notifiers {
+		for _, event := range sorted {
+			if !notifier.Filter.Matches(event) {
+				continue
+			}
+			err := notifier.Post(event)
+			if err != nil {
+				logger.Warn("Posting notification failed", "notify", notifier.Name, "event", event.ID, "error", err)
+			}
+		}
+	}
+}
+
+// Post sends the message of an event to the webhook
+func (n *Notifier) Post(event Event) error {
+	payload, err := n.Payload(event)
+	if err != nil {
+		return err
+	}
+
+	return n.postJSON(payload)
+}
+
+func (n *Notifier) postJSON(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(interrupted, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("the webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+// PostDigest sends a digest as a single message, Slack gets it in its own
+// mrkdwn and Discord in Markdown cut to the 2000 characters it accepts
+func (n *Notifier) PostDigest(digest Digest) error {
+	var payload map[string]interface{}
+	if n.Kind == "discord" {
+		var text strings.Builder
+		writeDigestMarkdown(&text, digest)
+		content := text.String()
+		if runes := []rune(content); len(runes) > 2000 {
+			content = string(runes[:1999]) + "…"
+		}
+		payload = map[string]interface{}{"content": content}
+	} else {
+		payload = map[string]interface{}{"text": slackDigest(digest)}
+	}
+
+	return n.postJSON(payload)
+}
+
+func slackDigest(digest Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n%s\n", slackEscape(digest.Heading()), slackEscape(digest.Overview()))
+	for _, section := range digest.Sections {
+		fmt.Fprintf(&b, "\n*%s*\n", section.Title)
+		for _, item := range section.Items {
+			text := slackEscape(item.Text)
+			if item.URL != "" {
+				text = fmt.Sprintf("<%s|%s>", item.URL, text)
+			}
+			if item.Title != "" {
+				text += ": " + slackEscape(item.Title)
+			}
+			if item.Actor != "" {
+				text += " by " + slackEscape(item.Actor)
+			}
+			fmt.Fprintf(&b, "• %s %s\n", item.Date, text)
+		}
+	}
+
+	return b.String()
+}
+
+// Payload is the JSON body of the message: Slack blocks or a Discord embed
+func (n *Notifier) Payload(event Event) (interface{}, error) {
+	message := NotifyMessage{
+		Event:    event,
+		Sentence: describeEvent(event),
+		URL:      eventHTMLURL(event),
+		Actor:    event.Actor.Login,
+		Repo:     event.Repo.Name,
+		Type:     event.Type,
+	}
+	var text strings.Builder
+	err := n.Template.Execute(&text, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.Kind == "discord" {
+		return discordPayload(message, text.String()), nil
+	}
+	return slackPayload(message, text.String()), nil
+}
+
+func slackPayload(message NotifyMessage, text string) map[string]interface{} {
+	linked := slackEscape(text)
+	if message.URL != "" {
+		linked = fmt.Sprintf("<%s|%s>", message.URL, linked)
+	}
+	context := slackEscape(fmt.Sprintf("%s · %s · %s", message.Actor, message.Type, message.Event.CreatedAt.Format(time.RFC1123)))
+
+	return map[string]interface{}{
+		// Shown by notifications and clients that don't render blocks
+		"text": text,
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]string{"type": "mrkdwn", "text": linked},
+			},
+			map[string]interface{}{
+				"type":     "context",
+				"elements": []interface{}{map[string]string{"type": "mrkdwn", "text": context}},
+			},
+		},
+	}
+}
+
+// Slack mrkdwn only needs &, < and > escaped
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+func discordPayload(message NotifyMessage, text string) map[string]interface{} {
+	// Discord rejects embed titles longer than 256 characters
+	if runes := []rune(text); len(runes) > 256 {
+		text = string(runes[:255]) + "…"
+	}
+	embed := map[string]interface{}{
+		"title":     text,
+		"timestamp": message.Event.CreatedAt.Format(time.RFC3339),
+		"footer":    map[string]string{"text": message.Type},
+	}
+	if message.URL != "" {
+		embed["url"] = message.URL
+	}
+	if message.Actor != "" {
+		embed["author"] = map[string]string{"name": message.Actor}
+	}
+	if message.Event.Target.Title != "" {
+		embed["description"] = message.Event.Target.Title
+	}
+
+	return map[string]interface{}{"embeds": []interface{}{embed}}
+}
+
+// Add --notify to a command, the returned function loads the webhooks of
+// the config file when it is set
+func addNotifyFlag(flags *flag.FlagSet) func(config Config) {
+	notify := flags.Bool("notify", false, "post the new events to the webhooks of the [notify.*] sections of the config file")
+
+	return func(config Config) {
+		if !*notify {
+			return
+		}
+		loaded, err := loadNotifiers(config)
+		if err != nil {
+			log.Fatalf("Error loading notifiers: %v", err)
+		}
+		if len(loaded) == 0 {
+			log.Fatalf("--notify needs a [notify.name] section with a webhook url in the config file")
+		}
+		notifiers = loaded
+	}
+}
+
+// notify list | test [name] shows the configured webhooks and posts a sample
+// event to them
+func runNotify(args []string) {
+	flags := newFlagSet("notify")
+	_ = flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) < 1 || (args[0] != "list" && args[0] != "test") {
+		log.Fatalf("Usage: notify list | test [name]")
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	loaded, err := loadNotifiers(config)
+	if err != nil {
+		log.Fatalf("Error loading notifiers: %v", err)
+	}
+
+	if args[0] == "list" {
+		for _, notifier := range loaded {
+			// The path of a webhook URL is its secret
+			host := notifier.URL
+			if parsed, err := url.Parse(notifier.URL); err == nil {
+				host = parsed.Host
+			}
+			fmt.Printf("%s: %s %s\n", notifier.Name, notifier.Kind, host)
+		}
+		return
+	}
+
+	sample := Event{
+		ID:        "0",
+		Type:      "WatchEvent",
+		Action:    ActionStarred,
+		Target:    EventTarget{Kind: TargetRepository},
+		Actor:     EventActor{Login: "octocat"},
+		Repo:      EventRepo{Name: "octocat/Hello-World", URL: "https://api.github.com/repos/octocat/Hello-World"},
+		Provider:  "github",
+		CreatedAt: time.Now(),
+	}
+	posted := 0
+	for _, notifier := range loaded {
+		if len(args) > 1 && notifier.Name != args[1] {
+			continue
+		}
+		err = notifier.Post(sample)
+		if err != nil {
+			log.Fatalf("Error posting to %s: %v", notifier.Name, err)
+		}
+		fmt.Printf("Posted a sample event to %s\n", notifier.Name)
+		posted++
+	}
+	if posted == 0 {
+		log.Fatalf("No notifier to test, defined notifiers: %s", strings.Join(configSectionNames(config, "notify"), ", "))
+	}
+}
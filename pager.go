@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Don't page the output, set with --no-pager
+var noPager bool
+
+// The pager while it runs, os.Stdout writes to its input
+var pager *exec.Cmd
+
+// The write end of the pipe to the pager, it stands in for the terminal
+var pagerInput *os.File
+
+// The terminal os.Stdout was before the pager started
+var pagerTerminal *os.File
+
+// Page the output through $GITHUB_ACTIVITY_PAGER, $PAGER or less like git
+// does, when stdout is a terminal. LESS defaults to FRX so less quits at once
+// when the output fits the screen and keeps the colors. An empty pager or cat
+// turns paging off. Returns whether the pager started, stopPager waits until
+// it is closed.
+func startPager() bool {
+	if noPager || pager != nil || !isTerminal(os.Stdout) || runtime.GOOS == "windows" {
+		return false
+	}
+	command, ok := os.LookupEnv("GITHUB_ACTIVITY_PAGER")
+	if !ok {
+		command, ok = os.LookupEnv("PAGER")
+	}
+	if !ok {
+		command = "less"
+	}
+	if command == "" || command == "cat" {
+		return false
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		logger.Warn("Starting the pager failed", "pager", command, "error", err)
+		return false
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = reader
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	err = cmd.Start()
+	reader.Close()
+	if err != nil {
+		writer.Close()
+		logger.Warn("Starting the pager failed", "pager", command, "error", err)
+		return false
+	}
+
+	pager = cmd
+	pagerInput = writer
+	pagerTerminal = os.Stdout
+	os.Stdout = writer
+
+	return true
+}
+
+// Close the input of the pager and wait until the user quits it, before the
+// command exits
+func stopPager() {
+	if pager == nil {
+		return
+	}
+
+	pagerInput.Close()
+	_ = pager.Wait()
+	os.Stdout = pagerTerminal
+	pager, pagerInput, pagerTerminal = nil, nil, nil
+}
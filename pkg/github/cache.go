@@ -0,0 +1,228 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CacheItem is a cached response, Data is the JSON the caller stored
+type CacheItem struct {
+	Data      json.RawMessage
+	ExpiresAt time.Time
+	// Sent as If-None-Match once the item expired, a 304 only extends it
+	ETag string `json:",omitempty"`
+	// When the item was last stored or read with Get, the least recently
+	// used items are evicted first
+	UsedAt time.Time `json:",omitempty"`
+}
+
+// When the item was last used, items saved before UsedAt existed count as
+// used when they were fetched
+func (i CacheItem) lastUsed() time.Time {
+	if i.UsedAt.IsZero() {
+		return i.ExpiresAt
+	}
+	return i.UsedAt
+}
+
+// Expired reports whether the item should be fetched again
+func (i CacheItem) Expired() bool {
+	return !time.Now().Before(i.ExpiresAt)
+}
+
+// Cache keeps items in memory and saves them to a JSON file, it is safe for
+// concurrent use
+type Cache struct {
+	Path string
+	// Load and Save drop the items expired longer than Retention ago, and
+	// then the least recently used items over MaxEntries. Zero keeps them.
+	Retention  time.Duration
+	MaxEntries int
+	mutex      sync.Mutex
+	items      map[string]CacheItem
+}
+
+// NewCache returns an empty cache saved to path
+func NewCache(path string) *Cache {
+	return &Cache{Path: path, items: make(map[string]CacheItem)}
+}
+
+// CorruptError is returned by Load when the file isn't a valid cache, e.g.
+// truncated by a full disk or edited by hand
+type CorruptError struct {
+	Path string
+	Err  error
+}
+
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("cache file %s is corrupted: %v", e.Path, e.Err)
+}
+
+func (e *CorruptError) Unwrap() error {
+	return e.Err
+}
+
+// IsCorrupt reports whether err is a *CorruptError
+func IsCorrupt(err error) bool {
+	var corrupt *CorruptError
+	return errors.As(err, &corrupt)
+}
+
+// Load reads the items saved in the file, a missing file returns an error
+// for which os.IsNotExist is true and a file that can't be decoded a
+// *CorruptError, both leave the cache empty
+func (c *Cache) Load() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items = make(map[string]CacheItem)
+	file, err := os.ReadFile(c.Path)
+	if err != nil {
+		return err
+	}
+
+	items := make(map[string]CacheItem)
+	err = json.Unmarshal(file, &items)
+	if err != nil {
+		return &CorruptError{Path: c.Path, Err: err}
+	}
+	for key, item := range items {
+		if item.ExpiresAt.IsZero() {
+			return &CorruptError{Path: c.Path, Err: fmt.Errorf("item %q has no expiry", key)}
+		}
+	}
+	c.items = items
+	c.prune(time.Now())
+
+	return nil
+}
+
+// Save writes every item to the file, creating its directory. The items are
+// written to a temporary file next to it that replaces the file once it is
+// on the disk, so an interrupted save or two processes saving at once leave
+// a complete file.
+func (c *Cache) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.prune(time.Now())
+	file, err := json.MarshalIndent(c.items, "", " ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(c.Path), 0755)
+	if err != nil {
+		return err
+	}
+
+	temporary, err := os.CreateTemp(filepath.Dir(c.Path), filepath.Base(c.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	_, err = temporary.Write(file)
+	if err == nil {
+		err = temporary.Sync()
+	}
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// CreateTemp makes the file private, the cache was always readable
+		err = os.Chmod(temporary.Name(), 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporary.Name(), c.Path)
+}
+
+// Get returns the item of a key, expired or not, and marks it used
+func (c *Cache) Get(key string) (CacheItem, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, found := c.items[key]
+	if found {
+		item.UsedAt = time.Now()
+		c.items[key] = item
+	}
+	return item, found
+}
+
+// Peek returns the item of a key like Get without marking it used, for
+// listing the items
+func (c *Cache) Peek(key string) (CacheItem, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, found := c.items[key]
+	return item, found
+}
+
+// Set stores an item under a key
+func (c *Cache) Set(key string, item CacheItem) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item.UsedAt = time.Now()
+	c.items[key] = item
+}
+
+func (c *Cache) prune(now time.Time) int {
+	pruned := 0
+	if c.Retention > 0 {
+		for key, item := range c.items {
+			if item.ExpiresAt.Add(c.Retention).Before(now) {
+				delete(c.items, key)
+				pruned++
+			}
+		}
+	}
+
+	if c.MaxEntries > 0 && len(c.items) > c.MaxEntries {
+		keys := make([]string, 0, len(c.items))
+		for key := range c.items {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return c.items[keys[i]].lastUsed().Before(c.items[keys[j]].lastUsed())
+		})
+		for _, key := range keys[:len(keys)-c.MaxEntries] {
+			delete(c.items, key)
+			pruned++
+		}
+	}
+
+	return pruned
+}
+
+// Delete removes the item of a key
+func (c *Cache) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.items, key)
+}
+
+// Keys returns the keys of every item, sorted
+func (c *Cache) Keys() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	keys := make([]string, 0, len(c.items))
+	for key := range c.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
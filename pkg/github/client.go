@@ -0,0 +1,470 @@
+// Package github is a small client for the Github REST API, the events of
+// users and a file cache for the responses. It is what github-activity-cli
+// uses and can be embedded in other programs.
+package github
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultBaseURL is the API of github.com
+const DefaultBaseURL = "https://api.github.com"
+
+// Client sends requests to the Github API
+type Client struct {
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+	// BaseURL of the API, DefaultBaseURL when empty. Github Enterprise
+	// Server serves it at https://<host>/api/v3.
+	BaseURL string
+	// Token is sent as a Bearer token when not empty
+	Token string
+	// RetryRateLimited sleeps until an exceeded rate limit resets and sends
+	// the request again instead of returning the error
+	RetryRateLimited bool
+	// OnRateLimited, when not nil, is called before sleeping for the rate
+	// limit, e.g. to log it
+	OnRateLimited func(err *Error, wait time.Duration)
+	// Retries is how many times a request that failed with a transient error
+	// is sent again, with a jittered exponential backoff between attempts
+	Retries int
+	// OnRetry, when not nil, is called before sleeping for a retry
+	OnRetry func(err error, attempt int, wait time.Duration)
+	// Context of the requests built by NewRequest, canceling it aborts them
+	// and the wait for the rate limit. context.Background() when nil.
+	Context context.Context
+}
+
+// NewTransport returns a transport for one HTTP client shared by every
+// request: it speaks HTTP/2 when the server does, and keeps enough idle
+// connections per host that paging and fetching many users at once reuse
+// them instead of dialing and handshaking TLS again
+func NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 16
+	transport.IdleConnTimeout = 90 * time.Second
+
+	return transport
+}
+
+// NormalizeBaseURL checks the API URL of github.com or of a Github
+// Enterprise Server and returns it in the form the client expects: with a
+// scheme, https when missing, and no trailing slash. github.com is
+// DefaultBaseURL and an Enterprise Server host alone gets its /api/v3 path,
+// so github.mycorp.com becomes https://github.mycorp.com/api/v3.
+func NormalizeBaseURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("the API URL is empty")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid API URL %q: %v", raw, err)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return "", fmt.Errorf("invalid API URL %q: the scheme must be https or http", raw)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("invalid API URL %q: the host is missing", raw)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("invalid API URL %q: it can't have a query or a fragment", raw)
+	}
+
+	host := strings.ToLower(parsed.Host)
+	if host == "github.com" || host == "api.github.com" {
+		return DefaultBaseURL, nil
+	}
+	path := strings.TrimRight(parsed.Path, "/")
+	if path == "" {
+		path = "/api/v3"
+	}
+
+	return parsed.Scheme + "://" + host + path, nil
+}
+
+// GraphQLURL returns the GraphQL endpoint of the API at baseURL, Github
+// Enterprise Server serves it at /api/graphql next to /api/v3
+func GraphQLURL(baseURL string) string {
+	if baseURL == "" || baseURL == DefaultBaseURL {
+		return DefaultBaseURL + "/graphql"
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v3") + "/graphql"
+}
+
+// NewClient returns a client with the given HTTP client, base URL and token,
+// nil and empty values use the defaults
+func NewClient(httpClient *http.Client, baseURL, token string) *Client {
+	return &Client{HTTPClient: httpClient, BaseURL: baseURL, Token: token}
+}
+
+// Error is returned for a non 200 response, the message is the one sent by
+// the API when there is one
+type Error struct {
+	StatusCode int
+	Message    string
+	// Where to authorize the token when an organization enforces SAML SSO
+	SSOURL string
+	// When the exceeded rate limit resets, zero for other errors
+	RateLimitReset time.Time
+	// Too many requests in a short time rather than the hourly quota, the
+	// request can be sent again at RateLimitReset
+	SecondaryRateLimit bool
+}
+
+func (e *Error) Error() string {
+	if e.SSOURL != "" {
+		return fmt.Sprintf("%s, authorize the token for the organization at %s", e.Message, e.SSOURL)
+	}
+	// The message of Github is a paragraph pointing to its documentation
+	if e.SecondaryRateLimit {
+		return fmt.Sprintf("secondary rate limit exceeded by too many requests at once, retry after %s (in %s)", e.RateLimitReset.Local().Format("15:04:05"), time.Until(e.RateLimitReset).Round(time.Second))
+	}
+	if !e.RateLimitReset.IsZero() {
+		return fmt.Sprintf("%s, the rate limit resets at %s (in %s)", e.Message, e.RateLimitReset.Local().Format("15:04:05"), time.Until(e.RateLimitReset).Round(time.Second))
+	}
+
+	return e.Message
+}
+
+// ErrorResponse is the body of an error response
+type ErrorResponse struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+	Status           string `json:"status"`
+}
+
+// HasStatus reports whether err is an *Error with the given status code
+func HasStatus(err error, statusCode int) bool {
+	var apiError *Error
+	return errors.As(err, &apiError) && apiError.StatusCode == statusCode
+}
+
+// IsTransient reports whether a request that failed with err is worth
+// sending again: a 5xx response, a secondary rate limit, or a connection
+// reset or closed by the server. An empty body ends in a bare io.EOF from
+// the decoder, that is a bad response and isn't sent again.
+func IsTransient(err error) bool {
+	var apiError *Error
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode >= 500 || apiError.SecondaryRateLimit
+	}
+	// The server closed the connection before answering
+	var transportError *url.Error
+	if errors.As(err, &transportError) && errors.Is(transportError.Err, io.EOF) {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// How long to wait after a secondary rate limit without Retry-After, Github
+// asks for at least a minute
+const SecondaryRateLimitWait = time.Minute
+
+// isSecondaryRateLimit reports whether a 403 or 429 response is a secondary
+// rate limit: Github says so in the message, older servers call it abuse
+// detection, or it sends Retry-After while the hourly quota isn't spent
+func isSecondaryRateLimit(statusCode int, message string, header http.Header) bool {
+	if statusCode != http.StatusForbidden && statusCode != http.StatusTooManyRequests {
+		return false
+	}
+	message = strings.ToLower(message)
+	if strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse detection") {
+		return true
+	}
+
+	return header.Get("Retry-After") != "" && header.Get("X-RateLimit-Remaining") != "0"
+}
+
+// The first retry waits around a second, every next one twice as long up to
+// retryMaxWait
+const (
+	retryBaseWait = time.Second
+	retryMaxWait  = 30 * time.Second
+)
+
+// Half of the backoff of the attempt plus a random part of the other half,
+// so clients failing together don't retry together
+func retryWait(attempt int) time.Duration {
+	wait := retryMaxWait
+	if attempt < 6 {
+		wait = retryBaseWait << uint(attempt-1)
+	}
+	if wait > retryMaxWait {
+		wait = retryMaxWait
+	}
+
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// RateLimit is the request quota reported by the rate limit headers
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// ParseRateLimit reads the rate limit headers, prefix is "X-RateLimit-" for
+// Github and most other forges
+func ParseRateLimit(header http.Header, prefix string) RateLimit {
+	var rateLimit RateLimit
+	if header == nil {
+		return rateLimit
+	}
+
+	rateLimit.Limit, _ = strconv.Atoi(header.Get(prefix + "Limit"))
+	rateLimit.Remaining, _ = strconv.Atoi(header.Get(prefix + "Remaining"))
+	reset, err := strconv.ParseInt(header.Get(prefix+"Reset"), 10, 64)
+	if err == nil {
+		rateLimit.Reset = time.Unix(reset, 0)
+	}
+
+	return rateLimit
+}
+
+// RateLimitReset returns when an exceeded rate limit resets, from
+// Retry-After or the rate limit headers of Github and Gitlab, zero when the
+// response isn't rate limited
+func RateLimitReset(header http.Header) time.Time {
+	retryAfter := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	// Retry-After can also be a date
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return date
+	}
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		if header.Get(prefix+"Remaining") != "0" {
+			continue
+		}
+		if reset := ParseRateLimit(header, prefix).Reset; !reset.IsZero() {
+			return reset
+		}
+	}
+
+	return time.Time{}
+}
+
+// ParseSSOHeader reads the X-GitHub-SSO header, it holds "required; url=..."
+// when the token must be authorized for an organization and
+// "partial-results; organizations=..." when results of some organizations
+// were left out
+func ParseSSOHeader(header http.Header) (string, string) {
+	sso := header.Get("X-GitHub-SSO")
+	if sso == "" {
+		return "", ""
+	}
+
+	parts := strings.SplitN(sso, ";", 2)
+	state := strings.TrimSpace(parts[0])
+	value := ""
+	if len(parts) == 2 {
+		value = strings.TrimSpace(parts[1])
+		if i := strings.Index(value, "="); i >= 0 {
+			value = value[i+1:]
+		}
+	}
+
+	return state, value
+}
+
+// NextPageURL returns the URL of the next page from a Link header, empty on
+// the last page
+func NextPageURL(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+
+	return ""
+}
+
+// NewRequest builds a request for a path of the API, or for an absolute URL
+// like the next page of a Link header, with the token set
+func (c *Client) NewRequest(method, path string, body io.Reader) (*http.Request, error) {
+	requestUrl := path
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		baseURL := c.BaseURL
+		if baseURL == "" {
+			baseURL = DefaultBaseURL
+		}
+		requestUrl = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	return req, nil
+}
+
+// Get sends a GET request for a path or URL and decodes the JSON response
+// into v
+func (c *Client) Get(path string, v interface{}) (http.Header, error) {
+	req, err := c.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req, v)
+}
+
+// Do sends the request and decodes the JSON response into v, the response
+// headers are returned so callers can inspect rate limits. It works for any
+// request, the other forges' APIs answer in the same shape.
+func (c *Client) Do(req *http.Request, v interface{}) (http.Header, error) {
+	retries := 0
+	for {
+		header, err := c.send(req, v)
+		if err == nil {
+			return header, nil
+		}
+
+		var wait time.Duration
+		var apiError *Error
+		isAPIError := errors.As(err, &apiError)
+		switch {
+		case c.RetryRateLimited && isAPIError && !apiError.RateLimitReset.IsZero():
+			wait = time.Until(apiError.RateLimitReset) + time.Second
+			if c.OnRateLimited != nil {
+				c.OnRateLimited(apiError, wait)
+			}
+		case retries < c.Retries && IsTransient(err) && req.Context().Err() == nil:
+			retries++
+			wait = retryWait(retries)
+			// A secondary rate limit tells how long to wait with Retry-After
+			if isAPIError && time.Until(apiError.RateLimitReset) > wait {
+				wait = time.Until(apiError.RateLimitReset)
+			}
+			if c.OnRetry != nil {
+				c.OnRetry(err, retries, wait)
+			}
+		default:
+			return header, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return header, req.Context().Err()
+		}
+		// The body of a POST was read by the first attempt
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return header, err
+			}
+		}
+	}
+}
+
+func (c *Client) send(req *http.Request, v interface{}) (http.Header, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// Asked for explicitly so the responses are compressed whatever
+	// transport sends the request, the transport then leaves the body as is
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		// The connection is only reused once its body was read to the end
+		_, _ = io.Copy(io.Discard, io.LimitReader(Body, 64<<10))
+		err := Body.Close()
+		if err != nil {
+			return
+		}
+	}(resp.Body)
+	body, err := decodedBody(resp)
+	if err != nil {
+		return resp.Header, err
+	}
+
+	// Handling if the resource is not found or error occurred
+	if resp.StatusCode != http.StatusOK {
+		apiError := &Error{StatusCode: resp.StatusCode, Message: resp.Status}
+		var errorResponse ErrorResponse
+		err = json.NewDecoder(body).Decode(&errorResponse)
+		if err == nil && errorResponse.Message != "" {
+			apiError.Message = errorResponse.Message
+		}
+		if state, value := ParseSSOHeader(resp.Header); state == "required" {
+			apiError.SSOURL = value
+		}
+		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+			apiError.RateLimitReset = RateLimitReset(resp.Header)
+			apiError.SecondaryRateLimit = isSecondaryRateLimit(resp.StatusCode, apiError.Message, resp.Header)
+			if apiError.SecondaryRateLimit && apiError.RateLimitReset.IsZero() {
+				apiError.RateLimitReset = time.Now().Add(SecondaryRateLimitWait)
+			}
+		}
+
+		return resp.Header, apiError
+	}
+
+	// Decoded as the body arrives instead of read whole first, a page of
+	// events is never held twice
+	err = json.NewDecoder(body).Decode(v)
+	if err == io.EOF {
+		err = errors.New("decoding the response: empty body")
+	}
+
+	return resp.Header, err
+}
+
+// The body of a response, decompressed when the server gzipped it
+func decodedBody(resp *http.Response) (io.Reader, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") || resp.Uncompressed {
+		return resp.Body, nil
+	}
+	// Bodies of 304 and HEAD responses are empty even when gzipped
+	if resp.StatusCode == http.StatusNotModified || resp.ContentLength == 0 {
+		return resp.Body, nil
+	}
+
+	return gzip.NewReader(resp.Body)
+}
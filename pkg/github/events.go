@@ -0,0 +1,234 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Github serves at most 300 events of a user, in pages of up to 100
+const (
+	MaxEventPages = 10
+	MaxPerPage    = 100
+)
+
+// Event is an event of the events API, the payload is kept raw since its
+// shape depends on the type, DecodePayload turns it into the struct of the
+// type
+type Event struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Actor struct {
+		Login string `json:"login"`
+	} `json:"actor"`
+	Repo struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"repo"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// EventsOptions controls how many events are fetched
+type EventsOptions struct {
+	// PerPage is the number of events of each page, 30 when zero
+	PerPage int
+	// MaxPages is the number of pages followed, 1 when zero
+	MaxPages int
+	// Limit stops once this many events were fetched, zero is no limit
+	Limit int
+	// IfNoneMatch is sent with the first page, the fetch then fails with a
+	// 304 *Error when the events didn't change
+	IfNoneMatch string
+	// StopAt is the ID of the newest event already seen, paging stops at the
+	// page that has it and that event and the older ones are left out
+	StopAt string
+}
+
+// ValidateUsername checks a login against the Github rules locally: 1 to 39
+// alphanumerics or single hyphens, not starting or ending with a hyphen. The
+// bot users of apps have [bot] after such a login, like dependabot[bot].
+func ValidateUsername(username string) error {
+	return validateLogin(username, strings.TrimSuffix(username, "[bot]"))
+}
+
+// Check the login part of username, the whole of it unless it is a bot
+func validateLogin(username string, login string) error {
+	switch {
+	case username == "":
+		return fmt.Errorf("username is empty")
+	case login == "":
+		return fmt.Errorf("invalid Github username %q: the login is empty", username)
+	case len(login) > 39:
+		return fmt.Errorf("invalid Github username %q: longer than 39 characters", username)
+	case strings.HasPrefix(login, "-") || strings.HasSuffix(login, "-"):
+		return fmt.Errorf("invalid Github username %q: cannot start or end with a hyphen", username)
+	case strings.Contains(login, "--"):
+		return fmt.Errorf("invalid Github username %q: cannot contain consecutive hyphens", username)
+	}
+
+	for _, r := range login {
+		isAlphanumeric := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlphanumeric && r != '-' {
+			return fmt.Errorf("invalid Github username %q: only letters, digits and hyphens are allowed, found %q", username, r)
+		}
+	}
+
+	return nil
+}
+
+// UserEventPages follows the Link header of the events of a user and calls
+// onPage with the raw events and the response headers of every page, in
+// order. The headers of the last response are returned, also on error, so
+// the rate limit can be read.
+func (c *Client) UserEventPages(username string, options EventsOptions, onPage func(events []json.RawMessage, header http.Header)) (http.Header, error) {
+	err := ValidateUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.eventPages(fmt.Sprintf("users/%s/events", url.PathEscape(username)), options, onPage)
+}
+
+// ReceivedEventPages is UserEventPages for the events a user receives, the
+// activity of the users and repositories they follow and watch
+func (c *Client) ReceivedEventPages(username string, options EventsOptions, onPage func(events []json.RawMessage, header http.Header)) (http.Header, error) {
+	err := ValidateUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.eventPages(fmt.Sprintf("users/%s/received_events", url.PathEscape(username)), options, onPage)
+}
+
+// OrgEventPages is UserEventPages for the public events of an organization
+func (c *Client) OrgEventPages(org string, options EventsOptions, onPage func(events []json.RawMessage, header http.Header)) (http.Header, error) {
+	// Organization logins follow the same rules as usernames
+	err := ValidateUsername(org)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.eventPages(fmt.Sprintf("orgs/%s/events", url.PathEscape(org)), options, onPage)
+}
+
+// RepoEventPages is UserEventPages for the events of a repository given as
+// owner/name
+func (c *Client) RepoEventPages(fullName string, options EventsOptions, onPage func(events []json.RawMessage, header http.Header)) (http.Header, error) {
+	err := ValidateRepository(fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.eventPages(fmt.Sprintf("repos/%s/events", fullName), options, onPage)
+}
+
+// ValidateRepository checks an owner/name repository locally: a valid owner
+// login and a name of up to 100 letters, digits, dots, hyphens or
+// underscores
+func ValidateRepository(fullName string) error {
+	parts := strings.Split(fullName, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid repository %q, expected owner/name", fullName)
+	}
+	err := validateLogin(parts[0], parts[0])
+	if err != nil {
+		return err
+	}
+
+	name := parts[1]
+	if name == "" || len(name) > 100 || name == "." || name == ".." {
+		return fmt.Errorf("invalid repository name %q", name)
+	}
+	for _, r := range name {
+		isAlphanumeric := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlphanumeric && r != '-' && r != '_' && r != '.' {
+			return fmt.Errorf("invalid repository name %q: only letters, digits, dots, hyphens and underscores are allowed, found %q", name, r)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) eventPages(path string, options EventsOptions, onPage func(events []json.RawMessage, header http.Header)) (http.Header, error) {
+	maxPages := options.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+	if options.PerPage > 0 {
+		path += fmt.Sprintf("?per_page=%d", options.PerPage)
+	}
+
+	var header http.Header
+	fetched := 0
+	for page := 0; page < maxPages && path != ""; page++ {
+		req, err := c.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			return header, err
+		}
+		// Newer events shift every page, so an unchanged first page means
+		// nothing changed
+		if page == 0 && options.IfNoneMatch != "" {
+			req.Header.Set("If-None-Match", options.IfNoneMatch)
+		}
+
+		var events []json.RawMessage
+		header, err = c.Do(req, &events)
+		if err != nil {
+			return header, err
+		}
+
+		if options.Limit > 0 && fetched+len(events) > options.Limit {
+			events = events[:options.Limit-fetched]
+		}
+		known := false
+		if options.StopAt != "" {
+			for i, raw := range events {
+				if eventID(raw) == options.StopAt {
+					events, known = events[:i], true
+					break
+				}
+			}
+		}
+		fetched += len(events)
+		onPage(events, header)
+		if known || options.Limit > 0 && fetched >= options.Limit {
+			break
+		}
+		path = NextPageURL(header)
+	}
+
+	return header, nil
+}
+
+func eventID(raw json.RawMessage) string {
+	var event struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(raw, &event)
+
+	return event.ID
+}
+
+// UserEvents returns the events of a user, most recent first
+func (c *Client) UserEvents(username string, options EventsOptions) ([]Event, error) {
+	var events []Event
+	var decodeErr error
+	_, err := c.UserEventPages(username, options, func(page []json.RawMessage, header http.Header) {
+		for _, raw := range page {
+			var event Event
+			if err := json.Unmarshal(raw, &event); err != nil && decodeErr == nil {
+				decodeErr = err
+			}
+			events = append(events, event)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, decodeErr
+}
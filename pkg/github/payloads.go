@@ -0,0 +1,193 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// User is the account an event payload refers to
+type User struct {
+	Login string `json:"login"`
+}
+
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	User    User   `json:"user"`
+}
+
+type PullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	Merged  bool   `json:"merged"`
+	User    User   `json:"user"`
+}
+
+type Comment struct {
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	User    User   `json:"user"`
+}
+
+type PushCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"author"`
+	Distinct bool   `json:"distinct"`
+	URL      string `json:"url"`
+}
+
+type PushEventPayload struct {
+	Ref          string       `json:"ref"`
+	Head         string       `json:"head"`
+	Before       string       `json:"before"`
+	Size         int          `json:"size"`
+	DistinctSize int          `json:"distinct_size"`
+	Commits      []PushCommit `json:"commits"`
+}
+
+type CreateEventPayload struct {
+	Ref          string `json:"ref"`
+	RefType      string `json:"ref_type"`
+	MasterBranch string `json:"master_branch"`
+	Description  string `json:"description"`
+}
+
+type DeleteEventPayload struct {
+	Ref     string `json:"ref"`
+	RefType string `json:"ref_type"`
+}
+
+type IssuesEventPayload struct {
+	Action string `json:"action"`
+	Issue  Issue  `json:"issue"`
+}
+
+type IssueCommentEventPayload struct {
+	Action  string  `json:"action"`
+	Issue   Issue   `json:"issue"`
+	Comment Comment `json:"comment"`
+}
+
+type PullRequestEventPayload struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+type PullRequestReviewEventPayload struct {
+	Action string `json:"action"`
+	Review struct {
+		State   string `json:"state"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+	} `json:"review"`
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+type PullRequestReviewCommentEventPayload struct {
+	Action      string      `json:"action"`
+	Comment     Comment     `json:"comment"`
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+type CommitCommentEventPayload struct {
+	Comment Comment `json:"comment"`
+}
+
+type WatchEventPayload struct {
+	Action string `json:"action"`
+}
+
+type ForkEventPayload struct {
+	Forkee struct {
+		FullName string `json:"full_name"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"forkee"`
+}
+
+type ReleaseEventPayload struct {
+	Action  string `json:"action"`
+	Release struct {
+		TagName    string `json:"tag_name"`
+		Name       string `json:"name"`
+		HTMLURL    string `json:"html_url"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+	} `json:"release"`
+}
+
+type MemberEventPayload struct {
+	Action string `json:"action"`
+	Member User   `json:"member"`
+}
+
+type PublicEventPayload struct{}
+
+type GollumEventPayload struct {
+	Pages []struct {
+		PageName string `json:"page_name"`
+		Title    string `json:"title"`
+		Action   string `json:"action"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"pages"`
+}
+
+// The payload type of every event type, keyed on the type of the event
+var payloadTypes = map[string]func() interface{}{
+	"PushEvent":                     func() interface{} { return &PushEventPayload{} },
+	"CreateEvent":                   func() interface{} { return &CreateEventPayload{} },
+	"DeleteEvent":                   func() interface{} { return &DeleteEventPayload{} },
+	"IssuesEvent":                   func() interface{} { return &IssuesEventPayload{} },
+	"IssueCommentEvent":             func() interface{} { return &IssueCommentEventPayload{} },
+	"PullRequestEvent":              func() interface{} { return &PullRequestEventPayload{} },
+	"PullRequestReviewEvent":        func() interface{} { return &PullRequestReviewEventPayload{} },
+	"PullRequestReviewCommentEvent": func() interface{} { return &PullRequestReviewCommentEventPayload{} },
+	"CommitCommentEvent":            func() interface{} { return &CommitCommentEventPayload{} },
+	"WatchEvent":                    func() interface{} { return &WatchEventPayload{} },
+	"ForkEvent":                     func() interface{} { return &ForkEventPayload{} },
+	"ReleaseEvent":                  func() interface{} { return &ReleaseEventPayload{} },
+	"MemberEvent":                   func() interface{} { return &MemberEventPayload{} },
+	"PublicEvent":                   func() interface{} { return &PublicEventPayload{} },
+	"GollumEvent":                   func() interface{} { return &GollumEventPayload{} },
+}
+
+// EventTypes returns the event types DecodePayload has a struct for, sorted
+func EventTypes() []string {
+	types := make([]string, 0, len(payloadTypes))
+	for eventType := range payloadTypes {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// DecodePayload decodes the payload into the struct of the event type, e.g.
+// a *PushEventPayload for a PushEvent. The payload of a type without a
+// struct is returned as it is, a json.RawMessage.
+func (e Event) DecodePayload() (interface{}, error) {
+	newPayload, ok := payloadTypes[e.Type]
+	if !ok {
+		return e.Payload, nil
+	}
+
+	payload := newPayload()
+	if len(e.Payload) == 0 {
+		return payload, nil
+	}
+	err := json.Unmarshal(e.Payload, payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s payload: %v", e.Type, err)
+	}
+
+	return payload, nil
+}
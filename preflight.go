@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Token scopes needed by operations beyond public data, any one of the
+// listed scopes is enough
+var operationScopes = map[string][]string{
+	"private events": {"repo"},
+	"notifications":  {"notifications", "repo"},
+	"traffic stats":  {"repo"},
+	"audit log":      {"read:audit_log", "admin:org", "admin:enterprise"},
+}
+
+var authStatusCache = make(map[string]AuthStatus)
+var authStatusMutex sync.Mutex
+
+// Fetch the status of a token once per run
+func cachedAuthStatus(token string) (AuthStatus, error) {
+	authStatusMutex.Lock()
+	defer authStatusMutex.Unlock()
+
+	if status, ok := authStatusCache[token]; ok {
+		return status, nil
+	}
+	status, err := fetchAuthStatus(token)
+	if err != nil {
+		return AuthStatus{}, err
+	}
+	authStatusCache[token] = status
+
+	return status, nil
+}
+
+// Why the token can't be used for the operation, empty when it can or when
+// Github doesn't report scopes for it (fine-grained tokens)
+func missingScopes(status AuthStatus, operation string) string {
+	required := operationScopes[operation]
+	if len(required) == 0 || len(status.Scopes) == 0 {
+		return ""
+	}
+
+	for _, scope := range status.Scopes {
+		for _, allowed := range required {
+			if scope == allowed {
+				return ""
+			}
+		}
+	}
+
+	return fmt.Sprintf("the token of %s has scopes %s, %s needs one of %s",
+		status.Login, strings.Join(status.Scopes, ", "), operation, strings.Join(required, ", "))
+}
+
+// Print a warning on stderr before an operation the token isn't allowed to
+// do, so the user doesn't get a bare 403 or silently partial results later.
+// Returns false when a warning was printed.
+func preflight(token string, operation string) bool {
+	if token == "" {
+		logger.Warn(operation + " need a token, pass --token or set GITHUB_TOKEN")
+		return false
+	}
+
+	status, err := cachedAuthStatus(token)
+	if err != nil {
+		logger.Warn("Could not check the token for "+operation, "error", err)
+		return false
+	}
+	if missing := missingScopes(status, operation); missing != "" {
+		logger.Warn(missing)
+		return false
+	}
+
+	return true
+}
+
+// Own events include private ones only with the repo scope, warn when the
+// token can't see them. Other users' events are public, nothing to check.
+func preflightOwnEvents(token string, usernames []string) {
+	if token == "" {
+		return
+	}
+
+	status, err := cachedAuthStatus(token)
+	if err != nil {
+		return
+	}
+	for _, username := range usernames {
+		if strings.EqualFold(username, status.Login) {
+			preflight(token, "private events")
+			return
+		}
+	}
+}
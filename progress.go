@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Progress line drawn on stderr during long fetches: pages fetched, users
+// completed and the rate limit left. It does nothing until started.
+type progress struct {
+	mutex      sync.Mutex
+	enabled    bool
+	shown      bool
+	frame      int
+	pages      int
+	users      int
+	totalUsers int
+	rateLimit  RateLimit
+	stop       chan struct{}
+}
+
+var fetchProgress = &progress{}
+
+// Whether the file is a terminal rather than a pipe or a regular file, the
+// input of the pager counts as the terminal it shows the output on
+func isTerminal(file *os.File) bool {
+	if pagerInput != nil && file == pagerInput {
+		return true
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start drawing the progress when stderr is a terminal, totalUsers is zero
+// when the number of users isn't known up front
+func (p *progress) Start(totalUsers int) {
+	if !isTerminal(os.Stderr) {
+		return
+	}
+
+	p.mutex.Lock()
+	p.enabled = true
+	p.totalUsers = totalUsers
+	p.stop = make(chan struct{})
+	p.mutex.Unlock()
+
+	// Keep the spinner turning while a request is in flight
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				p.mutex.Lock()
+				p.frame++
+				p.draw()
+				p.mutex.Unlock()
+			}
+		}
+	}(p.stop)
+}
+
+// Count a fetched page and remember the rate limit it reported
+func (p *progress) Page(rateLimit RateLimit) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.pages++
+	if rateLimit.Limit > 0 {
+		p.rateLimit = rateLimit
+	}
+	p.draw()
+}
+
+func (p *progress) UserDone() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.users++
+	p.draw()
+}
+
+// Erase the line so regular output can be printed, the next update draws
+// it again
+func (p *progress) Clear() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.clear()
+}
+
+func (p *progress) Stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.enabled {
+		return
+	}
+	p.clear()
+	p.enabled = false
+	close(p.stop)
+}
+
+func (p *progress) clear() {
+	if p.shown {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+		p.shown = false
+	}
+}
+
+func (p *progress) draw() {
+	if !p.enabled {
+		return
+	}
+
+	parts := []string{fmt.Sprintf("%d pages", p.pages)}
+	if p.totalUsers > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d users", p.users, p.totalUsers))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d users", p.users))
+	}
+	if p.rateLimit.Limit > 0 {
+		parts = append(parts, fmt.Sprintf("rate limit %d/%d", p.rateLimit.Remaining, p.rateLimit.Limit))
+	}
+
+	frame := spinnerFrames[p.frame%len(spinnerFrames)]
+	fmt.Fprintf(os.Stderr, "\r\x1b[K%s %s", frame, strings.Join(parts, ", "))
+	p.shown = true
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// A Provider fetches the activity of a user from a forge. New forges or
+// internal source control systems are added by implementing this interface
+// and calling RegisterProvider from an init function.
+type Provider interface {
+	// Name identifies the provider instance, it is also used in cache keys
+	Name() string
+	// FetchEvents returns the events of a user as the forge sent them
+	FetchEvents(username string) ([]json.RawMessage, error)
+	// Normalize maps a single raw event into the common event model
+	Normalize(raw json.RawMessage) (Event, error)
+	// RateLimitInfo reports the rate limit seen in the last response
+	RateLimitInfo() RateLimit
+}
+
+// A provider that fetches several pages implements PagedProvider so callers
+// can use each page as soon as it arrives
+type PagedProvider interface {
+	Provider
+	// FetchEventPages calls page with the raw events of every page in order
+	FetchEventPages(username string, page func([]json.RawMessage)) error
+}
+
+// A provider that tells how often it may be polled implements
+// PollingProvider, follow mode never polls faster
+type PollingProvider interface {
+	Provider
+	// PollInterval from the last fetch, zero when the provider didn't say
+	PollInterval() time.Duration
+}
+
+// A provider that supports conditional requests implements
+// ConditionalProvider, the cache keeps the ETag of the events so an
+// unchanged feed isn't downloaded again
+type ConditionalProvider interface {
+	Provider
+	// SetETag makes the next fetch fail with a 304 error when the events
+	// didn't change, an empty ETag fetches unconditionally
+	SetETag(etag string)
+	// ETag of the events returned by the last fetch
+	ETag() string
+}
+
+type ProviderConfig struct {
+	Token   string
+	BaseURL string
+	// Pages is the maximum number of pages fetched by paged providers, zero
+	// is the provider default
+	Pages int
+	// Limit stops paged providers once they fetched that many events, zero
+	// is no limit
+	Limit int
+	// Feed is the kind of names events are fetched for, FeedUser or one of
+	// the other feeds of the providers that support them
+	Feed string
+	// StopAt is the ID of the newest event already stored, paged providers
+	// stop at the page that has it and leave that event and the older ones
+	// out
+	StopAt string
+}
+
+// Kinds of feed, users by default
+const (
+	FeedUser     = ""
+	FeedOrg      = "org"
+	FeedRepo     = "repo"
+	FeedReceived = "received"
+)
+
+// The request quota of a provider, every forge reports it like Github
+type RateLimit = github.RateLimit
+
+type ProviderFactory func(config ProviderConfig) (Provider, error)
+
+var providerFactories = make(map[string]ProviderFactory)
+var providerFactoriesMutex sync.RWMutex
+
+// Make a provider available under the given name, registering the same name
+// twice replaces the previous factory
+func RegisterProvider(name string, factory ProviderFactory) {
+	providerFactoriesMutex.Lock()
+	defer providerFactoriesMutex.Unlock()
+
+	providerFactories[name] = factory
+}
+
+func providerNames() []string {
+	providerFactoriesMutex.RLock()
+	defer providerFactoriesMutex.RUnlock()
+
+	names := make([]string, 0, len(providerFactories))
+	for name := range providerFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func newProvider(name string, config ProviderConfig) (Provider, error) {
+	providerFactoriesMutex.RLock()
+	factory, ok := providerFactories[name]
+	providerFactoriesMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", name)
+	}
+
+	return factory(config)
+}
+
+// The events of a page before the one with the ID stopAt, and whether it was
+// found, for providers that page through events with numeric or string IDs
+func eventsBefore(events []json.RawMessage, stopAt string) ([]json.RawMessage, bool) {
+	if stopAt == "" {
+		return events, false
+	}
+	for i, raw := range events {
+		var event struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if json.Unmarshal(raw, &event) == nil && strings.Trim(string(event.ID), `"`) == stopAt {
+			return events[:i], true
+		}
+	}
+
+	return events, false
+}
+
+// Fetch the events of a user and map every one of them into the common model,
+// onPage, when not nil, gets the normalized events of every page as it
+// arrives. Providers that don't page call it once.
+func streamNormalizedEvents(provider Provider, username string, onPage func([]Event)) ([]Event, error) {
+	var events []Event
+	var normalizeErr error
+	handlePage := func(rawEvents []json.RawMessage) {
+		if normalizeErr != nil {
+			return
+		}
+		page := make([]Event, 0, len(rawEvents))
+		for _, raw := range rawEvents {
+			event, err := provider.Normalize(raw)
+			if err != nil {
+				normalizeErr = fmt.Errorf("normalizing %s event: %v", provider.Name(), err)
+				return
+			}
+			page = append(page, event)
+		}
+		events = append(events, page...)
+		if onPage != nil {
+			onPage(page)
+		}
+	}
+
+	if paged, ok := provider.(PagedProvider); ok {
+		err := paged.FetchEventPages(username, handlePage)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rawEvents, err := provider.FetchEvents(username)
+		if err != nil {
+			return nil, err
+		}
+		handlePage(rawEvents)
+	}
+	if normalizeErr != nil {
+		return nil, normalizeErr
+	}
+
+	return events, nil
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// Time limit to connect to Redis and for each command
+const redisTimeout = 5 * time.Second
+
+// Every cache key is stored under this prefix, so the cache can share a
+// database with other programs
+const redisKeyPrefix = "github-activity:"
+
+// A Redis client speaking RESP over a single connection, enough for the
+// commands the cache sends. It is safe for concurrent use.
+type redisClient struct {
+	address  string
+	username string
+	password string
+	db       int
+
+	mutex  sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// An error reply of the server, e.g. a wrong password
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// Parse redis://[[user]:password@]host[:port][/db]
+func newRedisClient(value string) (*redisClient, error) {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "redis" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Redis URL %q, expected redis://[:password@]host[:port][/db]", value)
+	}
+
+	client := &redisClient{address: parsed.Host}
+	if parsed.Port() == "" {
+		client.address = net.JoinHostPort(parsed.Hostname(), "6379")
+	}
+	if parsed.User != nil {
+		client.username = parsed.User.Username()
+		client.password, _ = parsed.User.Password()
+	}
+	if db := strings.Trim(parsed.Path, "/"); db != "" {
+		client.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+
+	return client, nil
+}
+
+func (c *redisClient) connect() error {
+	conn, err := net.DialTimeout("tcp", c.address, redisTimeout)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+
+	if c.password != "" {
+		args := []string{"AUTH", c.password}
+		if c.username != "" {
+			args = []string{"AUTH", c.username, c.password}
+		}
+		_, err = c.send(args)
+		if err != nil {
+			c.close()
+			return err
+		}
+	}
+	if c.db != 0 {
+		_, err = c.send([]string{"SELECT", strconv.Itoa(c.db)})
+		if err != nil {
+			c.close()
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *redisClient) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// Do sends a command and returns its reply: a string, nil, an int64 or a
+// []interface{} of them. A broken connection is opened again once.
+func (c *redisClient) Do(args ...string) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if c.conn == nil {
+			err := c.connect()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		reply, err := c.send(args)
+		var replyErr redisError
+		if err == nil || errors.As(err, &replyErr) {
+			return reply, err
+		}
+		// The server closed an idle connection, or it broke in the middle
+		// of the reply and can't be read further
+		c.close()
+		if attempt > 0 {
+			return nil, err
+		}
+	}
+}
+
+func (c *redisClient) send(args []string) (interface{}, error) {
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	err := c.conn.SetDeadline(time.Now().Add(redisTimeout))
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(c.conn, command.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return c.readReply()
+}
+
+func (c *redisClient) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(c.reader, data)
+		if err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			items[i], err = c.readReply()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// The items are kept in Redis, so several instances of serve share them.
+// Every write goes to the server at once and Redis drops an item when it
+// expires, so there is no ETag left to revalidate it with.
+type redisCache struct {
+	url    string
+	client *redisClient
+	// Set when the URL can't be parsed, returned by Load
+	err error
+}
+
+func newRedisCache(location string) Cache {
+	client, err := newRedisClient(location)
+	return &redisCache{url: location, client: client, err: err}
+}
+
+// Load checks that the server answers, the items stay in Redis. A server
+// that doesn't answer is only reported: like a failing Get it reads as an
+// empty cache and the events are fetched.
+func (c *redisCache) Load() error {
+	if c.err != nil {
+		return c.err
+	}
+
+	_, err := c.client.Do("PING")
+	if err != nil {
+		logger.Warn("The Redis cache is unreachable, fetching without it", "url", c.Location(), "error", err)
+	}
+
+	return nil
+}
+
+func (c *redisCache) Save() error {
+	return nil
+}
+
+// A failing server is reported and reads as a cache miss, the fetch goes on
+func (c *redisCache) Get(key string) (github.CacheItem, bool) {
+	if c.err != nil {
+		return github.CacheItem{}, false
+	}
+
+	reply, err := c.client.Do("GET", redisKeyPrefix+key)
+	if err != nil {
+		logger.Warn("Reading the Redis cache failed", "key", key, "error", err)
+		return github.CacheItem{}, false
+	}
+	data, ok := reply.(string)
+	if !ok {
+		return github.CacheItem{}, false
+	}
+
+	var item github.CacheItem
+	err = json.Unmarshal([]byte(data), &item)
+	if err != nil {
+		logger.Warn("Decoding the Redis cache failed", "key", key, "error", err)
+		return github.CacheItem{}, false
+	}
+
+	return item, true
+}
+
+// Redis doesn't track use, the items expire at their TTL
+func (c *redisCache) Peek(key string) (github.CacheItem, bool) {
+	return c.Get(key)
+}
+
+func (c *redisCache) Set(key string, item github.CacheItem) {
+	if c.err != nil {
+		return
+	}
+
+	ttl := time.Until(item.ExpiresAt)
+	if ttl < time.Millisecond {
+		c.Delete(key)
+		return
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		logger.Warn("Encoding the Redis cache failed", "key", key, "error", err)
+		return
+	}
+	_, err = c.client.Do("SET", redisKeyPrefix+key, string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		logger.Warn("Writing the Redis cache failed", "key", key, "error", err)
+	}
+}
+
+func (c *redisCache) Delete(key string) {
+	if c.err != nil {
+		return
+	}
+
+	_, err := c.client.Do("DEL", redisKeyPrefix+key)
+	if err != nil {
+		logger.Warn("Deleting from the Redis cache failed", "key", key, "error", err)
+	}
+}
+
+func (c *redisCache) Keys() []string {
+	if c.err != nil {
+		return nil
+	}
+
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := c.client.Do("SCAN", cursor, "MATCH", redisKeyPrefix+"*", "COUNT", "100")
+		if err != nil {
+			logger.Warn("Listing the Redis cache failed", "error", err)
+			return nil
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			logger.Warn("Listing the Redis cache failed", "error", "unexpected SCAN reply")
+			return nil
+		}
+		cursor, _ = parts[0].(string)
+		batch, _ := parts[1].([]interface{})
+		for _, key := range batch {
+			if name, ok := key.(string); ok {
+				keys = append(keys, strings.TrimPrefix(name, redisKeyPrefix))
+			}
+		}
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+	// SCAN can return a key twice
+	keys = uniqueStrings(keys)
+	sort.Strings(keys)
+
+	return keys
+}
+
+// The URL without its password
+func (c *redisCache) Location() string {
+	parsed, err := url.Parse(c.url)
+	if err != nil {
+		return c.url
+	}
+
+	return parsed.Redacted()
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Set by --no-color, which every command accepts
+var noColor bool
+
+// Set by --absolute and --tz, event times are relative to now by default and
+// shown in the timezone they come with when no location is set
+var (
+	absoluteTimes   bool
+	displayLocation *time.Location
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// The color of the types of events, the others are left plain
+var eventTypeColors = map[string]string{
+	"PushEvent":                     ansiGreen,
+	"PullRequestEvent":              ansiMagenta,
+	"PullRequestReviewEvent":        ansiMagenta,
+	"PullRequestReviewCommentEvent": ansiMagenta,
+	"IssuesEvent":                   ansiYellow,
+	"IssueCommentEvent":             ansiYellow,
+	"CreateEvent":                   ansiCyan,
+	"DeleteEvent":                   ansiRed,
+	"ReleaseEvent":                  ansiCyan,
+	"WatchEvent":                    ansiBlue,
+	"ForkEvent":                     ansiBlue,
+}
+
+// Register --absolute and --tz, the returned function resolves them with
+// their environment variables and config keys once the flags are parsed
+func addTimeDisplayFlags(flags *flag.FlagSet) func(settings *settingsResolver) {
+	absolute := flags.Bool("absolute", false, "show dates and times instead of relative times like 2 hours ago")
+	tz := flags.String("tz", "", "timezone of the times shown, e.g. Asia/Jakarta or Local (default the one of the API)")
+
+	return func(settings *settingsResolver) {
+		for _, err := range []error{
+			settings.Resolve("absolute", "GITHUB_ACTIVITY_ABSOLUTE", "defaults.absolute"),
+			settings.Resolve("tz", "GITHUB_ACTIVITY_TZ", "defaults.tz"),
+		} {
+			if err != nil {
+				log.Fatalf("Error resolving settings: %v", err)
+			}
+		}
+		absoluteTimes = *absolute
+		if *tz != "" {
+			location, err := time.LoadLocation(*tz)
+			if err != nil {
+				log.Fatalf("Error loading timezone: %v", err)
+			}
+			displayLocation = location
+		}
+	}
+}
+
+// Format t as a date and time in location, or as it is when location is nil,
+// otherwise relative to now
+func formatTimestamp(t time.Time, now time.Time, absolute bool, location *time.Location) string {
+	if !absolute {
+		return formatRelative(t, now)
+	}
+	if location == nil {
+		return t.Format("2006-01-02 15:04:05")
+	}
+
+	return t.In(location).Format("2006-01-02 15:04:05 MST")
+}
+
+// Format the time between t and now like "2 hours ago", or "in 2 hours" when
+// t is after now
+func formatRelative(t time.Time, now time.Time) string {
+	elapsed := now.Sub(t)
+	future := elapsed < 0
+	if future {
+		elapsed = -elapsed
+	}
+
+	day := 24 * time.Hour
+	var count int
+	var unit string
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		count, unit = int(elapsed/time.Minute), "minute"
+	case elapsed < day:
+		count, unit = int(elapsed/time.Hour), "hour"
+	case elapsed < 30*day:
+		count, unit = int(elapsed/day), "day"
+	case elapsed < 365*day:
+		count, unit = int(elapsed/(30*day)), "month"
+	default:
+		count, unit = int(elapsed/(365*day)), "year"
+	}
+
+	if future {
+		return fmt.Sprintf("in %d %s", count, plural(count, unit))
+	}
+	return fmt.Sprintf("%d %s ago", count, plural(count, unit))
+}
+
+// Prints events as text blocks, colored when writing to a terminal unless
+// --no-color or NO_COLOR (https://no-color.org) turn it off
+type eventRenderer struct {
+	w     io.Writer
+	color bool
+}
+
+// Turn colors off when the config file sets defaults.color = false, before
+// the flags of the command are parsed
+func applyColorSettings(config Config) {
+	if !config.Bool("defaults.color", true) {
+		noColor = true
+	}
+}
+
+func newEventRenderer(file *os.File) *eventRenderer {
+	return &eventRenderer{
+		w:     file,
+		color: !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(file),
+	}
+}
+
+func (r *eventRenderer) paint(style string, text string) string {
+	if !r.color || style == "" || text == "" {
+		return text
+	}
+
+	return style + text + ansiReset
+}
+
+// The sentence of the event with the repository highlighted
+func (r *eventRenderer) sentence(event Event) string {
+	sentence := describeEvent(event)
+	if event.Repo.Name == "" {
+		return sentence
+	}
+
+	return strings.Replace(sentence, event.Repo.Name, r.paint(ansiBold, event.Repo.Name), 1)
+}
+
+// Timestamp formats t with --absolute and --tz, dimmed
+func (r *eventRenderer) Timestamp(t time.Time) string {
+	return r.paint(ansiDim, formatTimestamp(t, time.Now(), absoluteTimes, displayLocation))
+}
+
+// Field prints a "Key: value" line
+func (r *eventRenderer) Field(key string, value string) {
+	fmt.Fprintf(r.w, "%s: %s\n", key, value)
+}
+
+// Event prints the event as a block of lines
+func (r *eventRenderer) Event(event Event) {
+	if eventTemplate != nil {
+		r.Template(event)
+		return
+	}
+	fmt.Fprintln(r.w, r.sentence(event))
+	if event.Target.Title != "" && event.Action != ActionForked && event.Action != ActionJoined {
+		r.Field("Title", event.Target.Title)
+	}
+	if event.Target.State != nil {
+		r.Field("State", formatTargetState(*event.Target.State))
+	}
+	r.Field("ID", event.ID)
+	r.Field("Type", r.paint(eventTypeColors[event.Type], event.Type))
+	r.Field("Actor Login", event.Actor.Login)
+	// The web page rather than the API URL of Github events
+	r.Field("Repo URL", repoHTMLURL(event))
+	r.Field("Created At", r.Timestamp(event.CreatedAt))
+	for _, commit := range event.Commits {
+		// Commits are listed once their signatures were checked
+		if commit.Verification != "" {
+			fmt.Fprintln(r.w, formatCommit(commit))
+		}
+	}
+	for _, note := range eventNotes(event.ID) {
+		r.Field("Note", note.Text)
+	}
+}
+
+// Line prints the event on a single line with the time it happened at, as
+// follow mode does
+func (r *eventRenderer) Line(event Event) {
+	if eventTemplate != nil {
+		r.Template(event)
+		return
+	}
+	location := displayLocation
+	if location == nil {
+		location = time.Local
+	}
+	line := fmt.Sprintf("%s  %s", r.paint(ansiDim, event.CreatedAt.In(location).Format("01-02 15:04")), r.sentence(event))
+	if event.Target.Title != "" && event.Action != ActionForked && event.Action != ActionJoined {
+		line += "  " + event.Target.Title
+	}
+	if event.Target.State != nil {
+		line += "  " + r.paint(ansiDim, "["+formatTargetState(*event.Target.State)+"]")
+	}
+	fmt.Fprintln(r.w, line)
+}
+
+// Separator prints the line between two events
+func (r *eventRenderer) Separator() {
+	if eventTemplate != nil {
+		return
+	}
+	fmt.Fprintln(r.w, r.paint(ansiDim, "----------------------"))
+}
+
+// DayNotes prints the notes of the days of the events, oldest day first
+func (r *eventRenderer) DayNotes(events []Event) {
+	if eventTemplate != nil {
+		return
+	}
+	seen := make(map[string]bool)
+	var days []string
+	for i := len(events) - 1; i >= 0; i-- {
+		day := events[i].CreatedAt.Local().Format(dayLayout)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	// The events can be sorted oldest first
+	sort.Strings(days)
+
+	for _, day := range days {
+		for _, note := range dayNotes(day) {
+			r.Field("Note for "+r.paint(ansiDim, day), note.Text)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Repositories are matched against glob patterns of owner/name, where *
+// stays within a part: myorg/* is every repository of myorg and */infra-*
+// the infra- repositories of every owner. Forges treat the names case
+// insensitively, so does the matching.
+
+// Check that every pattern is a valid glob
+func validateRepoPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid repository pattern %q", pattern)
+		}
+	}
+
+	return nil
+}
+
+// Whether any of the patterns matches the repository
+func matchesRepo(patterns []string, repo string) bool {
+	repo = strings.ToLower(repo)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long local data is kept, zero keeps it forever
+type RetentionPolicy struct {
+	Cache     time.Duration
+	Archive   time.Duration
+	Snapshots time.Duration
+	// Events of the event store, older than what the events API returns or
+	// sync stores them again
+	Store time.Duration
+}
+
+func (p RetentionPolicy) IsZero() bool {
+	return p.Cache == 0 && p.Archive == 0 && p.Snapshots == 0 && p.Store == 0
+}
+
+// Parse a retention like 7d, 2w or 2y on top of the time.ParseDuration units
+func parseRetention(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+		"y": 365 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if strings.HasSuffix(value, suffix) {
+			count, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid retention %q", value)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention %q, expected a duration like 12h, 7d, 2w or 2y", value)
+	}
+
+	return duration, nil
+}
+
+// Read the [retention] section: cache, archive, snapshots and store
+func retentionFromConfig(config Config) (RetentionPolicy, error) {
+	var policy RetentionPolicy
+	for key, target := range map[string]*time.Duration{
+		"retention.cache":     &policy.Cache,
+		"retention.archive":   &policy.Archive,
+		"retention.snapshots": &policy.Snapshots,
+		"retention.store":     &policy.Store,
+	} {
+		value := config.String(key, "")
+		if value == "" {
+			continue
+		}
+		retention, err := parseRetention(value)
+		if err != nil {
+			return RetentionPolicy{}, fmt.Errorf("%s: %v", key, err)
+		}
+		*target = retention
+	}
+
+	return policy, nil
+}
+
+type PruneResult struct {
+	CacheEntries int
+	Bookmarks    int
+	Notes        int
+	Snapshots    int
+	StoredEvents int
+}
+
+func (r PruneResult) String() string {
+	return fmt.Sprintf("%d cache entries, %d bookmarks, %d notes, %d snapshots, %d stored events", r.CacheEntries, r.Bookmarks, r.Notes, r.Snapshots, r.StoredEvents)
+}
+
+// Drop the cache entries that expired longer than the cache retention ago
+func pruneCache(retention time.Duration, now time.Time) int {
+	pruned := 0
+	for _, key := range cache.Keys() {
+		item, _ := cache.Peek(key)
+		if item.ExpiresAt.Add(retention).Before(now) {
+			cache.Delete(key)
+			pruned++
+		}
+	}
+
+	return pruned
+}
+
+func pruneNotes(notes map[string][]Note, cutoff time.Time) int {
+	pruned := 0
+	for target, list := range notes {
+		kept := list[:0]
+		for _, note := range list {
+			if note.CreatedAt.Before(cutoff) {
+				pruned++
+				continue
+			}
+			kept = append(kept, note)
+		}
+		if len(kept) == 0 {
+			delete(notes, target)
+		} else {
+			notes[target] = kept
+		}
+	}
+
+	return pruned
+}
+
+// Drop the bookmarks and notes older than the archive retention
+func pruneArchive(retention time.Duration, now time.Time) (int, int) {
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	cutoff := now.Add(-retention)
+	bookmarks := 0
+	for id, bookmark := range archive.Bookmarks {
+		if bookmark.BookmarkedAt.Before(cutoff) {
+			delete(archive.Bookmarks, id)
+			bookmarks++
+		}
+	}
+	notes := pruneNotes(archive.EventNotes, cutoff) + pruneNotes(archive.DayNotes, cutoff)
+
+	return bookmarks, notes
+}
+
+func pruneSnapshots(retention time.Duration, now time.Time) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(snapshotDir(), "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, path := range paths {
+		snapshot, err := loadSnapshot(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return pruned, err
+		}
+		if snapshot.TakenAt.Add(retention).Before(now) {
+			err = os.Remove(path)
+			if err != nil {
+				return pruned, err
+			}
+			pruned++
+		}
+	}
+
+	return pruned, nil
+}
+
+// Apply the policy to the cache file, the archive, the snapshots and the
+// event store, when there is one
+func prune(policy RetentionPolicy, store EventStore) (PruneResult, error) {
+	var result PruneResult
+	now := time.Now()
+
+	if policy.Cache > 0 {
+		loadCache()
+		result.CacheEntries = pruneCache(policy.Cache, now)
+		if result.CacheEntries > 0 {
+			saveCache()
+		}
+	}
+
+	if policy.Archive > 0 {
+		err := loadArchive()
+		if err != nil {
+			return result, err
+		}
+		result.Bookmarks, result.Notes = pruneArchive(policy.Archive, now)
+		if result.Bookmarks+result.Notes > 0 {
+			err = saveArchive()
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if policy.Snapshots > 0 {
+		var err error
+		result.Snapshots, err = pruneSnapshots(policy.Snapshots, now)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if policy.Store > 0 && store != nil {
+		var err error
+		result.StoredEvents, err = store.Prune(now.Add(-policy.Store))
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// Commands prune the local data at most this often
+const autoPruneInterval = 24 * time.Hour
+
+// The file whose modification time is the last automatic prune
+func autoPrunePath() string {
+	return filepath.Join(dataDir(), "last-prune")
+}
+
+// Prune with the configured policy when a command starts, quietly as it
+// runs before every command, and once a day as the data only grows slowly
+func autoPrune() {
+	config, err := loadConfig()
+	if err != nil {
+		return
+	}
+	policy, err := retentionFromConfig(config)
+	if err != nil || policy.IsZero() {
+		return
+	}
+	if info, err := os.Stat(autoPrunePath()); err == nil && time.Since(info.ModTime()) < autoPruneInterval {
+		return
+	}
+
+	// The store the commands use by default, sync keeps events in a file
+	backend := os.Getenv("GITHUB_ACTIVITY_STORE")
+	if backend == "" {
+		backend = config.String("store.backend", "file")
+	}
+	path := os.Getenv("GITHUB_ACTIVITY_STORE_PATH")
+	if path == "" {
+		path = config.String("store.path", "")
+	}
+	store, err := openStore(backend, path)
+	if err != nil {
+		logger.Warn("Pruning local data failed", "error", err)
+		return
+	}
+
+	_, err = prune(policy, store)
+	if err != nil {
+		logger.Warn("Pruning local data failed", "error", err)
+	}
+	// A failed prune waits for the next day too, instead of warning on
+	// every command
+	err = os.MkdirAll(dataDir(), 0755)
+	if err == nil {
+		err = os.WriteFile(autoPrunePath(), nil, 0644)
+	}
+	if err != nil {
+		logger.Warn("Recording the last prune failed", "error", err)
+	}
+}
+
+// prune [--cache 7d] [--archive 2y] [--snapshots 1y] [--events 1y], flags
+// override the [retention] section of the config file
+func runPrune(args []string) {
+	flags := newFlagSet("prune")
+	cacheRetention := flags.String("cache", "", "drop cache entries expired longer ago than this, e.g. 7d (default retention.cache)")
+	archiveRetention := flags.String("archive", "", "drop bookmarks and notes older than this, e.g. 2y (default retention.archive)")
+	snapshotRetention := flags.String("snapshots", "", "drop snapshots older than this, e.g. 1y (default retention.snapshots)")
+	storeRetention := flags.String("events", "", "drop the events of the event store created longer ago than this, e.g. 1y (default retention.store)")
+	applyStore := addStoreFlags(flags, "file")
+	_ = flags.Parse(args)
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	policy, err := retentionFromConfig(config)
+	if err != nil {
+		log.Fatalf("Error reading retention: %v", err)
+	}
+	overrides := []struct {
+		value  string
+		target *time.Duration
+	}{
+		{*cacheRetention, &policy.Cache},
+		{*archiveRetention, &policy.Archive},
+		{*snapshotRetention, &policy.Snapshots},
+		{*storeRetention, &policy.Store},
+	}
+	for _, override := range overrides {
+		if override.value == "" {
+			continue
+		}
+		*override.target, err = parseRetention(override.value)
+		if err != nil {
+			log.Fatalf("Error parsing retention: %v", err)
+		}
+	}
+	if policy.IsZero() {
+		log.Fatalf("Nothing to prune, set a [retention] section in %s or pass --cache, --archive, --snapshots or --events", configPath())
+	}
+
+	applyStore(newSettingsResolver(flags, config))
+	result, err := prune(policy, eventStore)
+	if err != nil {
+		log.Fatalf("Error pruning: %v", err)
+	}
+	fmt.Printf("Pruned %s\n", result)
+}
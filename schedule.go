@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// When the daemon syncs: every interval, or at the times matching a crontab
+// line of minute, hour, day of month, month and day of week
+type schedule struct {
+	interval time.Duration
+	fields   [5]map[int]bool
+	// Like cron, a day matches either the day of month or the day of week
+	// when both are restricted
+	eitherDay bool
+}
+
+// The range of each crontab field, Sunday is 0
+var cronFieldRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// Parse a duration like 15m or a crontab line like "*/15 * * * *", fields
+// take *, numbers, ranges like 1-5, steps like */15 or 0-30/10, and lists of
+// them separated by commas
+func parseSchedule(value string) (schedule, error) {
+	if interval, err := time.ParseDuration(value); err == nil {
+		if interval < time.Minute {
+			return schedule{}, fmt.Errorf("schedule %q is shorter than a minute", value)
+		}
+		return schedule{interval: interval}, nil
+	}
+
+	parts := strings.Fields(value)
+	if len(parts) != 5 {
+		return schedule{}, fmt.Errorf("invalid schedule %q, expected a duration like 15m or a crontab line like \"*/15 * * * *\"", value)
+	}
+	var s schedule
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFieldRanges[i][0], cronFieldRanges[i][1])
+		if err != nil {
+			return schedule{}, fmt.Errorf("invalid schedule %q: %v", value, err)
+		}
+		s.fields[i] = values
+	}
+	s.eitherDay = !strings.HasPrefix(parts[2], "*") && !strings.HasPrefix(parts[4], "*")
+
+	return s, nil
+}
+
+func parseCronField(field string, min int, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", item)
+			}
+			item = item[:i]
+		}
+
+		from, to := min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			from, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", item)
+			}
+			to = from
+			if len(bounds) == 2 {
+				to, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", item)
+				}
+			} else if step > 1 {
+				// 5/15 is every 15 from 5
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%q is out of %d-%d", item, min, max)
+		}
+		for value := from; value <= to; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, nil
+}
+
+// The first time after t the schedule fires
+func (s schedule) Next(t time.Time) time.Time {
+	if s.interval > 0 {
+		return t.Add(s.interval)
+	}
+
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	// Every combination repeats within a few years, February 29th included
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if s.matches(next) {
+			return next
+		}
+		next = next.Add(time.Minute)
+	}
+
+	return limit
+}
+
+func (s schedule) matches(t time.Time) bool {
+	day := s.fields[2][t.Day()] && s.fields[4][int(t.Weekday())]
+	if s.eitherDay {
+		day = s.fields[2][t.Day()] || s.fields[4][int(t.Weekday())]
+	}
+
+	return s.fields[0][t.Minute()] && s.fields[1][t.Hour()] && s.fields[3][int(t.Month())] && day
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The local HTTP API of serve, answering from the same cache as the other
+// commands so dashboards don't hit the forges on every request
+type activityServer struct {
+	provider string
+	config   ProviderConfig
+}
+
+// Answer GET /users/{name}/events, GET /users/{name}/summary and GET
+// /badge/{name}
+func (s *activityServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	isBadge := len(parts) == 2 && parts[0] == "badge" && parts[1] != ""
+	if !isBadge && (len(parts) != 3 || parts[0] != "users" || parts[1] == "" || (parts[2] != "events" && parts[2] != "summary")) {
+		writeAPIError(w, http.StatusNotFound, "not found, expected /users/{name}/events, /users/{name}/summary or /badge/{name}")
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeAPIError(w, http.StatusMethodNotAllowed, "only GET is supported")
+		return
+	}
+	username := parts[1]
+	if isBadge {
+		s.serveBadge(w, r, strings.TrimSuffix(username, ".svg"))
+		return
+	}
+
+	filter, err := filterFromQuery(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Providers keep the state of their last request, every request gets
+	// its own
+	provider, err := newProvider(s.provider, s.config)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	events, err := getEvents(provider, username)
+	metrics.SetRateLimit(provider.Name(), provider.RateLimitInfo())
+	if err != nil {
+		status := http.StatusBadGateway
+		if isNotFound(err) {
+			status = http.StatusNotFound
+		}
+		writeAPIError(w, status, err.Error())
+		return
+	}
+	metrics.CountEvents(username, events)
+	events = filter.ApplyLimit(events)
+
+	if parts[2] == "summary" {
+		writeAPIJSON(w, http.StatusOK, summarizeEvents(username, events))
+		return
+	}
+	if events == nil {
+		events = []Event{}
+	}
+	writeAPIJSON(w, http.StatusOK, events)
+}
+
+// Answer GET /badge/{name} with the SVG badge of the kind, label and since
+// query parameters. Errors are badges too, so an embedded badge shows them.
+func (s *activityServer) serveBadge(w http.ResponseWriter, r *http.Request, username string) {
+	query := r.URL.Query()
+	kind := query.Get("kind")
+	if kind == "" {
+		kind = "events"
+	}
+	label := query.Get("label")
+	if label == "" {
+		label = "activity"
+	}
+	err := validateBadgeKind(kind)
+	if err != nil {
+		writeBadge(w, http.StatusBadRequest, Badge{Label: label, Message: "unknown kind", Color: badgeRed})
+		return
+	}
+	since := query.Get("since")
+	if since == "" {
+		since = badgeSince(kind)
+		query.Set("since", since)
+		r.URL.RawQuery = query.Encode()
+	}
+	filter, err := filterFromQuery(r)
+	if err != nil {
+		writeBadge(w, http.StatusBadRequest, Badge{Label: label, Message: "invalid query", Color: badgeRed})
+		return
+	}
+
+	provider, err := newProvider(s.provider, s.config)
+	if err != nil {
+		writeBadge(w, http.StatusInternalServerError, Badge{Label: label, Message: "error", Color: badgeRed})
+		return
+	}
+	badge, err := makeBadge(provider, username, kind, label, since, filter)
+	metrics.SetRateLimit(provider.Name(), provider.RateLimitInfo())
+	if err != nil {
+		if isNotFound(err) {
+			writeBadge(w, http.StatusNotFound, Badge{Label: label, Message: "user not found", Color: badgeGrey})
+			return
+		}
+		logger.Warn("Fetching the badge failed", "user", username, "error", err)
+		writeBadge(w, http.StatusBadGateway, Badge{Label: label, Message: "error", Color: badgeRed})
+		return
+	}
+
+	writeBadge(w, http.StatusOK, badge)
+}
+
+// Badges are cached for as long as the events, so README images don't hit
+// the server on every view
+func writeBadge(w http.ResponseWriter, status int, badge Badge) {
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(cacheTTL.Seconds())))
+	w.WriteHeader(status)
+	_, err := w.Write(badge.SVG())
+	if err != nil {
+		logger.Warn("Writing response failed", "error", err)
+	}
+}
+
+// The filter of the type, repo, since, until and limit query parameters
+func filterFromQuery(r *http.Request) (EventFilter, error) {
+	query := r.URL.Query()
+	filter := EventFilter{}
+	for _, value := range query["type"] {
+		var types stringList
+		_ = types.Set(value)
+		filter.Types = append(filter.Types, types...)
+	}
+	filter.Repos = query["repo"]
+	err := validateRepoPatterns(filter.Repos)
+	if err != nil {
+		return filter, err
+	}
+
+	if since := query.Get("since"); since != "" {
+		filter.Since, err = parseTimeBound(since, false)
+		if err != nil {
+			return filter, err
+		}
+	}
+	if until := query.Get("until"); until != "" {
+		filter.Until, err = parseTimeBound(until, true)
+		if err != nil {
+			return filter, err
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 0 {
+			return filter, errors.New("limit must be a positive number")
+		}
+	}
+
+	return filter, nil
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := writeJSON(w, v, false)
+	if err != nil {
+		logger.Warn("Writing response failed", "error", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeAPIJSON(w, status, map[string]string{"error": message})
+}
+
+// Log every request with its status and how long it took
+type loggedResponse struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *loggedResponse) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		response := &loggedResponse{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(response, r)
+		logger.Info("Request", "method", r.Method, "path", r.URL.RequestURI(), "status", response.status, "duration", time.Since(start).Round(time.Millisecond).String())
+	})
+}
+
+// serve [--listen 127.0.0.1:8080] exposes the events and summaries of users
+// as JSON, and Prometheus metrics at /metrics, until interrupted
+func runServe(args []string) {
+	flags := newFlagSet("serve")
+	listen := flags.String("listen", "127.0.0.1:8080", "address to listen on, e.g. :8080 for every interface")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	token := flags.String("token", "", "access token for the provider (defaults to GITHUB_TOKEN, GITLAB_TOKEN, GITEA_TOKEN or BITBUCKET_TOKEN)")
+	baseURL := flags.String("base-url", "", "base URL of a self-hosted provider instance")
+	pages := flags.Int("pages", 0, "maximum number of pages to fetch (default 1 for github)")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	applyStore := addStoreFlags(flags, "none")
+	_ = flags.Parse(args)
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	err = settings.Resolve("provider", "GITHUB_ACTIVITY_PROVIDER", "defaults.provider")
+	if err != nil {
+		log.Fatalf("Error resolving settings: %v", err)
+	}
+	envPrefix := providerEnvPrefix(*providerName)
+	for _, resolveErr := range []error{
+		settings.Resolve("listen", "GITHUB_ACTIVITY_LISTEN", "serve.listen"),
+		settings.ResolveSecret("token", envPrefix+"_TOKEN", *providerName+".token"),
+		settings.Resolve("base-url", envPrefix+"_BASE_URL", *providerName+".base_url"),
+		settings.Resolve("pages", "GITHUB_ACTIVITY_PAGES", "defaults.pages"),
+	} {
+		if resolveErr != nil {
+			log.Fatalf("Error resolving settings: %v", resolveErr)
+		}
+	}
+	applyTimeouts(settings)
+	applyCache(settings)
+	applyStore(settings)
+
+	handler := &activityServer{
+		provider: *providerName,
+		config:   ProviderConfig{Token: *token, BaseURL: *baseURL, Pages: *pages},
+	}
+	// Fail now on an unknown provider instead of on every request
+	_, err = newProvider(handler.provider, handler.config)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+	if *providerName == "github" && *token != "" {
+		githubToken = *token
+	}
+
+	loadCache()
+	err = loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+
+	metrics = newServeMetrics()
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpClient.Transport = countingTransport{next: transport}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	mux.Handle("/", handler)
+	server := &http.Server{Addr: *listen, Handler: logRequests(mux)}
+	cancelOnInterrupt()
+	go func() {
+		<-interrupted.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	fmt.Printf("Serving the activity API on http://%s, press Ctrl+C to stop\n", *listen)
+	err = server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Error serving: %v", err)
+	}
+	saveCache()
+}
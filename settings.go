@@ -0,0 +1,361 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// Where the effective value of a setting came from
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceConfig  = "config"
+	SourceDefault = "default"
+)
+
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+	// The flag, environment variable or config key the value was read from
+	Origin string
+	Secret bool
+}
+
+// Resolve flag values with a strict precedence: flags given on the command
+// line, then environment variables, then the config file, then the flag
+// defaults. The winning value is set on the flag so the typed flag
+// variables and their validation keep working.
+type settingsResolver struct {
+	flags    *flag.FlagSet
+	given    map[string]bool
+	config   Config
+	resolved []Setting
+}
+
+func newSettingsResolver(flags *flag.FlagSet, config Config) *settingsResolver {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	return &settingsResolver{flags: flags, given: given, config: config}
+}
+
+// Resolve the flag name from the environment variable env and the config
+// key, an empty env or key skips that source
+func (r *settingsResolver) Resolve(name string, env string, key string) error {
+	return r.resolve(name, env, key, false)
+}
+
+// Like Resolve for values that must not be printed, such as tokens
+func (r *settingsResolver) ResolveSecret(name string, env string, key string) error {
+	return r.resolve(name, env, key, true)
+}
+
+func (r *settingsResolver) resolve(name string, env string, key string, secret bool) error {
+	f := r.flags.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("unknown flag %s", name)
+	}
+
+	setting := Setting{Name: name, Value: f.DefValue, Source: SourceDefault, Secret: secret}
+	switch {
+	case r.given[name]:
+		setting.Value = f.Value.String()
+		setting.Source = SourceFlag
+		setting.Origin = "--" + name
+	case env != "" && os.Getenv(env) != "":
+		setting.Value = os.Getenv(env)
+		setting.Source = SourceEnv
+		setting.Origin = env
+	case key != "" && r.config[key] != nil:
+		setting.Value = configValueString(r.config[key])
+		setting.Source = SourceConfig
+		setting.Origin = key
+	}
+
+	if setting.Source == SourceEnv || setting.Source == SourceConfig {
+		err := r.flags.Set(name, setting.Value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s from %s %s: %v", setting.Value, name, setting.Source, setting.Origin, err)
+		}
+	}
+	r.resolved = append(r.resolved, setting)
+
+	return nil
+}
+
+func configValueString(value interface{}) string {
+	if list, ok := value.([]string); ok {
+		return strings.Join(list, ",")
+	}
+
+	return fmt.Sprint(value)
+}
+
+// Keep the start and end of a secret so the user can tell tokens apart
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+
+	return value[:4] + strings.Repeat("*", len(value)-8) + value[len(value)-4:]
+}
+
+// Print every resolved setting with its effective value and its source
+func (r *settingsResolver) Explain() {
+	path := configPath()
+	if _, err := os.Stat(path); err != nil {
+		path += " (not found)"
+	}
+	fmt.Printf("Config File: %s\n", path)
+	fmt.Println("Precedence: flags > env > config file > defaults")
+	fmt.Println("----------------------")
+
+	for _, setting := range r.resolved {
+		value := setting.Value
+		if setting.Secret {
+			value = maskSecret(value)
+		}
+		if value == "" {
+			value = "(empty)"
+		}
+
+		source := setting.Source
+		if setting.Origin != "" {
+			source += " " + setting.Origin
+		}
+		fmt.Printf("%s: %s [%s]\n", setting.Name, value, source)
+	}
+}
+
+// Token of a provider for the instance at baseURL, for commands without a
+// --token flag. The token of the environment variable or of <provider>.token
+// belongs to the instance of the provider and is never sent to another host,
+// those take theirs from <provider>.hosts.<host>.token.
+func providerToken(config Config, provider string, baseURL string) string {
+	if !sameHost(baseURL, providerBaseURL(config, provider)) {
+		return config.String(provider+".hosts."+urlHost(baseURL)+".token", "")
+	}
+	if token := os.Getenv(providerEnvPrefix(provider) + "_TOKEN"); token != "" {
+		return token
+	}
+
+	return config.String(provider+".token", "")
+}
+
+// The token of the environment variable of a provider created without one,
+// only when baseURL is the instance the token belongs to
+func defaultProviderToken(provider string, baseURL string) string {
+	if !sameHost(baseURL, providerBaseURL(Config{}, provider)) {
+		return ""
+	}
+
+	return os.Getenv(providerEnvPrefix(provider) + "_TOKEN")
+}
+
+// The public instances of the providers, Github's is the --api-url
+var publicBaseURLs = map[string]string{
+	"gitlab":    defaultGitlabBaseURL,
+	"gitea":     defaultGiteaBaseURL,
+	"forgejo":   defaultGiteaBaseURL,
+	"bitbucket": defaultBitbucketBaseURL,
+}
+
+// The instance a provider talks to when no base URL is given: the one of
+// its environment variable or <provider>.base_url, else the public one
+func providerBaseURL(config Config, provider string) string {
+	if baseURL := os.Getenv(providerEnvPrefix(provider) + "_BASE_URL"); baseURL != "" {
+		return baseURL
+	}
+	if baseURL := config.String(provider+".base_url", ""); baseURL != "" {
+		return baseURL
+	}
+	if provider == "github" {
+		return githubAPIURL
+	}
+
+	return publicBaseURLs[provider]
+}
+
+// The host and port of a base URL, lower-cased, e.g. git.mycorp.com
+func urlHost(baseURL string) string {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return strings.ToLower(baseURL)
+	}
+
+	return strings.ToLower(parsed.Host)
+}
+
+// Whether a base URL is on the host of the instance, an empty one is the
+// instance itself
+func sameHost(baseURL string, instance string) bool {
+	return baseURL == "" || urlHost(baseURL) == urlHost(instance)
+}
+
+// Register --token on a command that only talks to Github, the returned
+// function resolves it like the other settings once the flags are parsed and
+// sends it with every Github request
+func addGithubTokenFlag(flags *flag.FlagSet) func() {
+	flags.String("token", "", "Github token, raises the rate limit (defaults to GITHUB_TOKEN or github.token)")
+
+	return func() {
+		config, err := loadConfig()
+		if err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		err = newSettingsResolver(flags, config).ResolveSecret("token", "GITHUB_TOKEN", "github.token")
+		if err != nil {
+			log.Fatalf("Error resolving settings: %v", err)
+		}
+		githubToken = flags.Lookup("token").Value.String()
+	}
+}
+
+// Register --token and --base-url on a command that builds a provider, the
+// returned function resolves the provider of --provider, then its token and
+// base URL from its environment variables and config section like fetch,
+// into providerConfig. A token that isn't a flag is only sent to its host.
+func addProviderFlags(flags *flag.FlagSet) func(settings *settingsResolver, providerConfig *ProviderConfig) {
+	token := flags.String("token", "", "access token for the provider (defaults to GITHUB_TOKEN, GITLAB_TOKEN, GITEA_TOKEN or BITBUCKET_TOKEN)")
+	baseURL := flags.String("base-url", "", "base URL of a self-hosted provider instance")
+
+	return func(settings *settingsResolver, providerConfig *ProviderConfig) {
+		err := settings.Resolve("provider", "GITHUB_ACTIVITY_PROVIDER", "defaults.provider")
+		if err != nil {
+			log.Fatalf("Error resolving settings: %v", err)
+		}
+		name := flags.Lookup("provider").Value.String()
+		envPrefix := providerEnvPrefix(name)
+		for _, err := range []error{
+			settings.ResolveSecret("token", envPrefix+"_TOKEN", name+".token"),
+			settings.Resolve("base-url", envPrefix+"_BASE_URL", name+".base_url"),
+		} {
+			if err != nil {
+				log.Fatalf("Error resolving settings: %v", err)
+			}
+		}
+		if !settings.given["token"] {
+			*token = providerToken(settings.config, name, *baseURL)
+		}
+		providerConfig.Token = *token
+		providerConfig.BaseURL = *baseURL
+	}
+}
+
+// The cache settings the cache was last opened with, the defaults of the
+// flags of the command
+var (
+	cacheBackend = "file"
+	cacheFile    = defaultCachePath()
+	cacheURL     = "redis://localhost:6379"
+)
+
+// Register --cache-ttl, --cache-max-entries, --cache-backend, --cache-file,
+// --cache-url, --no-cache and --offline on a command that reads the cache,
+// the returned function resolves them like the other settings and opens the
+// cache
+func addCacheFlags(flags *flag.FlagSet) func(settings *settingsResolver) {
+	ttl := flags.Duration("cache-ttl", cacheTTL, "how long fetched events are cached, e.g. 30m")
+	maxEntries := flags.Int("cache-max-entries", cacheMaxEntries, "most feeds kept in the cache, the least recently used are dropped first (0 keeps every feed)")
+	backend := flags.String("cache-backend", cacheBackend, "where fetched events are cached: "+strings.Join(cacheBackendNames(), ", "))
+	file := flags.String("cache-file", cacheFile, "file the fetched events are cached in")
+	redisURL := flags.String("cache-url", cacheURL, "URL of the Redis server of the redis cache backend")
+	flags.BoolVar(&noCache, "no-cache", false, "fetch fresh events without reading or writing the cache")
+	flags.BoolVar(&offline, "offline", false, "only show the cached events, expired or not, and send no request")
+
+	return func(settings *settingsResolver) {
+		for _, err := range []error{
+			settings.Resolve("cache-ttl", "GITHUB_ACTIVITY_CACHE_TTL", "cache.ttl"),
+			settings.Resolve("cache-max-entries", "GITHUB_ACTIVITY_CACHE_MAX_ENTRIES", "cache.max_entries"),
+			settings.Resolve("cache-backend", "GITHUB_ACTIVITY_CACHE_BACKEND", "cache.backend"),
+			settings.Resolve("cache-file", "GITHUB_ACTIVITY_CACHE_FILE", "cache.file"),
+			settings.ResolveSecret("cache-url", "GITHUB_ACTIVITY_CACHE_URL", "cache.url"),
+		} {
+			if err != nil {
+				log.Fatalf("Error resolving settings: %v", err)
+			}
+		}
+		cacheTTL = *ttl
+		cacheMaxEntries = *maxEntries
+		if noCache && offline {
+			log.Fatalf("--offline only reads the cache, it can't be combined with --no-cache")
+		}
+		if offline {
+			httpClient.Transport = offlineTransport{}
+		}
+
+		newCache, ok := cacheBackends[*backend]
+		if !ok {
+			log.Fatalf("Unknown cache backend %q, expected one of %s", *backend, strings.Join(cacheBackendNames(), ", "))
+		}
+		if *backend != cacheBackend || *file != cacheFile || *redisURL != cacheURL {
+			cacheBackend, cacheFile, cacheURL = *backend, *file, *redisURL
+			location := cacheFile
+			if cacheBackend == "redis" {
+				location = cacheURL
+			}
+			cache = newCache(location)
+		}
+		limitCache(cache)
+	}
+}
+
+// Apply the cache, color and API URL settings of the environment and the config file
+// before any command runs, the flags of a command override them
+func applyGlobalSettings() {
+	config, err := loadConfig()
+	if err != nil {
+		// The command reports the config error itself
+		return
+	}
+
+	if policy, err := retentionFromConfig(config); err == nil && policy.Cache > 0 {
+		cacheRetention = policy.Cache
+	}
+	flags := flag.NewFlagSet("cache", flag.ContinueOnError)
+	applyCache := addCacheFlags(flags)
+	applyCache(newSettingsResolver(flags, config))
+	applyColorSettings(config)
+
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = config.String("github.api_url", "")
+	}
+	if apiURL != "" {
+		err = setGithubAPIURL(apiURL)
+		if err != nil {
+			log.Fatalf("Error resolving settings: %v", err)
+		}
+	}
+}
+
+// Normalize and use a Github API URL
+func setGithubAPIURL(value string) error {
+	apiURL, err := github.NormalizeBaseURL(value)
+	if err != nil {
+		return err
+	}
+	githubAPIURL = apiURL
+
+	return nil
+}
+
+// Environment variable prefix of a provider, GITLAB for gitlab
+func providerEnvPrefix(provider string) string {
+	return strings.ToUpper(strings.ReplaceAll(provider, "-", "_"))
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A point in time view of the activity of users, kept for audit style
+// reviews of what changed between two dates
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Provider  string    `json:"provider"`
+	Usernames []string  `json:"usernames"`
+	TakenAt   time.Time `json:"taken_at"`
+	Events    []Event   `json:"events"`
+}
+
+type CountDelta struct {
+	Name   string `json:"name"`
+	Before int    `json:"before"`
+	After  int    `json:"after"`
+}
+
+// What changed from snapshot a to snapshot b
+type SnapshotDiff struct {
+	From         string       `json:"from"`
+	To           string       `json:"to"`
+	NewEvents    []Event      `json:"new_events"`
+	NewRepos     []string     `json:"new_repos"`
+	DroppedRepos []string     `json:"dropped_repos"`
+	TypeDeltas   []CountDelta `json:"type_deltas"`
+	ActorDeltas  []CountDelta `json:"actor_deltas"`
+}
+
+func snapshotDir() string {
+	return filepath.Join(dataDir(), "snapshots")
+}
+
+func snapshotPath(name string) string {
+	return filepath.Join(snapshotDir(), name+".json")
+}
+
+func saveSnapshot(snapshot Snapshot) error {
+	file, err := json.MarshalIndent(snapshot, "", " ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(snapshotDir(), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(snapshotPath(snapshot.Name), file, 0644)
+}
+
+func loadSnapshot(name string) (Snapshot, error) {
+	file, err := os.ReadFile(snapshotPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Snapshot{}, fmt.Errorf("no snapshot named %s", name)
+		}
+		return Snapshot{}, err
+	}
+
+	var snapshot Snapshot
+	err = json.Unmarshal(file, &snapshot)
+	return snapshot, err
+}
+
+func countBy(events []Event, key func(Event) string) map[string]int {
+	counts := make(map[string]int)
+	for _, event := range events {
+		counts[key(event)]++
+	}
+
+	return counts
+}
+
+// Every name counted in either map whose count changed, sorted by name
+func countDeltas(before map[string]int, after map[string]int) []CountDelta {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	var deltas []CountDelta
+	for name := range names {
+		if before[name] != after[name] {
+			deltas = append(deltas, CountDelta{Name: name, Before: before[name], After: after[name]})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].Name < deltas[j].Name
+	})
+
+	return deltas
+}
+
+func diffSnapshots(a Snapshot, b Snapshot) SnapshotDiff {
+	diff := SnapshotDiff{From: a.Name, To: b.Name}
+
+	seen := make(map[string]bool)
+	for _, event := range a.Events {
+		seen[event.ID] = true
+	}
+	for _, event := range b.Events {
+		if !seen[event.ID] {
+			diff.NewEvents = append(diff.NewEvents, event)
+		}
+	}
+
+	repoOf := func(event Event) string { return event.Repo.Name }
+	reposBefore := countBy(a.Events, repoOf)
+	reposAfter := countBy(b.Events, repoOf)
+	for repo := range reposAfter {
+		if reposBefore[repo] == 0 {
+			diff.NewRepos = append(diff.NewRepos, repo)
+		}
+	}
+	for repo := range reposBefore {
+		if reposAfter[repo] == 0 {
+			diff.DroppedRepos = append(diff.DroppedRepos, repo)
+		}
+	}
+	sort.Strings(diff.NewRepos)
+	sort.Strings(diff.DroppedRepos)
+
+	typeOf := func(event Event) string { return event.Type }
+	actorOf := func(event Event) string { return event.Actor.Login }
+	diff.TypeDeltas = countDeltas(countBy(a.Events, typeOf), countBy(b.Events, typeOf))
+	diff.ActorDeltas = countDeltas(countBy(a.Events, actorOf), countBy(b.Events, actorOf))
+
+	return diff
+}
+
+func printCountDeltas(title string, deltas []CountDelta) {
+	if len(deltas) == 0 {
+		return
+	}
+
+	fmt.Printf("%s:\n", title)
+	for _, delta := range deltas {
+		fmt.Printf("  %s: %d -> %d (%+d)\n", delta.Name, delta.Before, delta.After, delta.After-delta.Before)
+	}
+}
+
+// snapshot save <name> <username>, snapshot diff <a> <b>, snapshot list
+func runSnapshot(args []string) {
+	flags := newFlagSet("snapshot")
+	_ = flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) < 1 {
+		log.Fatalf("Usage: snapshot save <name> <username> | snapshot diff <a> <b> | snapshot list")
+	}
+
+	switch args[0] {
+	case "save":
+		runSnapshotSave(args[1:])
+	case "diff":
+		runSnapshotDiff(args[1:])
+	case "list":
+		runSnapshotList()
+	default:
+		log.Fatalf("Unknown snapshot action %q, expected save, diff or list", args[0])
+	}
+}
+
+func runSnapshotSave(args []string) {
+	flags := newFlagSet("snapshot")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	applyProvider := addProviderFlags(flags)
+	applyTimeouts := addTimeoutFlags(flags)
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		log.Fatalf("Usage: snapshot save [--provider name] [--token token] [--base-url url] [--timeout 10s] [--deadline 2m] [--retries 3] <name> <username>")
+	}
+	name := flags.Arg(0)
+	if strings.ContainsAny(name, `/\`) {
+		log.Fatalf("Snapshot names can't contain slashes")
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	var providerConfig ProviderConfig
+	settings := newSettingsResolver(flags, config)
+	applyProvider(settings, &providerConfig)
+	applyTimeouts(settings)
+	provider, err := newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+
+	loadCache()
+	snapshot := Snapshot{
+		Name:      name,
+		Provider:  provider.Name(),
+		Usernames: expandUsername(config, flags.Arg(1)),
+		TakenAt:   time.Now(),
+	}
+	for _, username := range snapshot.Usernames {
+		events, err := getEvents(provider, username)
+		if err != nil {
+			exitIfInterrupted()
+			log.Fatalf("Error fetching events of %s: %v", username, err)
+		}
+		snapshot.Events = append(snapshot.Events, events...)
+	}
+
+	err = saveSnapshot(snapshot)
+	if err != nil {
+		log.Fatalf("Error saving snapshot: %v", err)
+	}
+	saveCache()
+
+	fmt.Printf("Saved snapshot %s with %d events of %s\n", name, len(snapshot.Events), strings.Join(snapshot.Usernames, ", "))
+}
+
+func runSnapshotDiff(args []string) {
+	flags := newFlagSet("snapshot")
+	format := flags.String("format", "text", "output format: text or json")
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		log.Fatalf("Usage: snapshot diff <a> <b> [--format text|json]")
+	}
+
+	a, err := loadSnapshot(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Error loading snapshot: %v", err)
+	}
+	b, err := loadSnapshot(flags.Arg(1))
+	if err != nil {
+		log.Fatalf("Error loading snapshot: %v", err)
+	}
+	diff := diffSnapshots(a, b)
+
+	switch *format {
+	case "json":
+		err = printJSON(diff)
+		if err != nil {
+			log.Fatalf("Error encoding diff: %v", err)
+		}
+	case "text":
+		fmt.Printf("From: %s (%s, %d events)\n", a.Name, a.TakenAt.Format("2006-01-02 15:04:05"), len(a.Events))
+		fmt.Printf("To: %s (%s, %d events)\n", b.Name, b.TakenAt.Format("2006-01-02 15:04:05"), len(b.Events))
+		fmt.Printf("New Events: %d\n", len(diff.NewEvents))
+		if len(diff.NewRepos) > 0 {
+			fmt.Printf("New Repos: %s\n", strings.Join(diff.NewRepos, ", "))
+		}
+		if len(diff.DroppedRepos) > 0 {
+			fmt.Printf("Dropped Repos: %s\n", strings.Join(diff.DroppedRepos, ", "))
+		}
+		printCountDeltas("Event Types", diff.TypeDeltas)
+		printCountDeltas("Actors", diff.ActorDeltas)
+		fmt.Println("----------------------")
+		for _, event := range diff.NewEvents {
+			fmt.Println(formatEventLine(event))
+		}
+	default:
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+}
+
+func runSnapshotList() {
+	paths, err := filepath.Glob(filepath.Join(snapshotDir(), "*.json"))
+	if err != nil {
+		log.Fatalf("Error listing snapshots: %v", err)
+	}
+
+	for _, path := range paths {
+		snapshot, err := loadSnapshot(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			log.Fatalf("Error loading snapshot %s: %v", path, err)
+		}
+		fmt.Printf("%s  %s  %d events of %s\n", snapshot.Name, snapshot.TakenAt.Format("2006-01-02 15:04:05"), len(snapshot.Events), strings.Join(snapshot.Usernames, ", "))
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The program the SQLite store runs its statements with, the database is a
+// plain SQLite file other tools can query too
+var sqliteCommand = "sqlite3"
+
+// Every script creates the table first, so a new database is ready on the
+// first write. Events are ordered by their creation time in nanoseconds.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS events (
+	key TEXT NOT NULL,
+	id TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	event TEXT NOT NULL,
+	PRIMARY KEY (key, id)
+);
+CREATE INDEX IF NOT EXISTS events_by_time ON events (key, created_at);
+`
+
+// The SQLite store keeps the events in a table keyed by feed and event ID,
+// so a stored event is never added twice
+type sqliteStore struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func newSQLiteStore(path string) EventStore {
+	return &sqliteStore{path: path}
+}
+
+// Quote a string as an SQL literal
+func sqliteQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// Run a script on the database and return what it prints, one row per line
+func (s *sqliteStore) run(script string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for another process writing to the database instead of failing
+	cmd := exec.Command(sqliteCommand, "-batch", "-bail", "-list", "-noheader", s.path)
+	cmd.Stdin = strings.NewReader(".timeout 5000\n" + sqliteSchema + script)
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("the sqlite store needs the %s program: %v", sqliteCommand, err)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("%s: %s", s.path, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+
+	return out, err
+}
+
+func (s *sqliteStore) Append(key string, events []Event) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	var script strings.Builder
+	script.WriteString("BEGIN;\n")
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return 0, err
+		}
+		fmt.Fprintf(&script, "INSERT OR IGNORE INTO events (key, id, created_at, event) VALUES (%s, %s, %d, %s);\n",
+			sqliteQuote(key), sqliteQuote(storedEventID(event)), event.CreatedAt.UnixNano(), sqliteQuote(string(data)))
+	}
+	// The rows inserted, the ignored duplicates don't count
+	script.WriteString("COMMIT;\nSELECT total_changes();\n")
+
+	out, err := s.run(script.String())
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+func (s *sqliteStore) Events(key string) ([]Event, error) {
+	out, err := s.run(fmt.Sprintf("SELECT event FROM events WHERE key = %s ORDER BY created_at DESC;\n", sqliteQuote(key)))
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var event Event
+		err = json.Unmarshal(line, &event)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", s.path, err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (s *sqliteStore) Prune(before time.Time) (int, error) {
+	// Pruning doesn't create the database
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	out, err := s.run(fmt.Sprintf("DELETE FROM events WHERE created_at < %d;\nSELECT changes();\n", before.UnixNano()))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
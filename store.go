@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An event store keeps every fetched event, so the history grows past the 90
+// days and 300 events the events APIs return. Events are stored under the
+// cache key of their feed and deduplicated by ID.
+type EventStore interface {
+	// Append stores the events it doesn't have yet and returns how many
+	Append(key string, events []Event) (int, error)
+	// Events returns every stored event of a feed, newest first
+	Events(key string) ([]Event, error)
+	// Prune drops the events of every feed created before the given time and
+	// returns how many
+	Prune(before time.Time) (int, error)
+}
+
+// The store of --store, nil when events aren't stored
+var eventStore EventStore
+
+// A store backend and the file it keeps the events in by default
+type storeBackend struct {
+	open func(path string) EventStore
+	file string
+}
+
+// Store backends by the name --store takes
+var storeBackends = map[string]storeBackend{
+	"file":   {newFileStore, "events.ndjson"},
+	"sqlite": {newSQLiteStore, "events.db"},
+}
+
+func storeBackendNames() []string {
+	names := make([]string, 0, len(storeBackends))
+	for name := range storeBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Register --store and --store-path on a command that fetches events, the
+// returned function resolves them like the other settings and opens the
+// store. The store is off by default unless defaultBackend names one.
+func addStoreFlags(flags *flag.FlagSet, defaultBackend string) func(settings *settingsResolver) {
+	backend := flags.String("store", defaultBackend, "keep every fetched event in a local store that summary and graph also read: none, "+strings.Join(storeBackendNames(), ", ")+" (sqlite runs the sqlite3 program, it must be installed)")
+	path := flags.String("store-path", "", "file or database of the event store (default events.ndjson or events.db in "+dataDir()+")")
+
+	return func(settings *settingsResolver) {
+		for _, err := range []error{
+			settings.Resolve("store", "GITHUB_ACTIVITY_STORE", "store.backend"),
+			settings.Resolve("store-path", "GITHUB_ACTIVITY_STORE_PATH", "store.path"),
+		} {
+			if err != nil {
+				log.Fatalf("Error resolving settings: %v", err)
+			}
+		}
+		store, err := openStore(*backend, *path)
+		if err != nil {
+			log.Fatalf("Error opening store: %v", err)
+		}
+		eventStore = store
+	}
+}
+
+// Open the store of a backend at path, or at the default file of the backend
+// when path is empty. Nil when the backend is none.
+func openStore(backend string, path string) (EventStore, error) {
+	if backend == "" || backend == "none" {
+		return nil, nil
+	}
+
+	store, ok := storeBackends[backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown store %q, expected one of none, %s", backend, strings.Join(storeBackendNames(), ", "))
+	}
+	if path == "" {
+		path = filepath.Join(dataDir(), store.file)
+	}
+
+	return store.open(path), nil
+}
+
+// Append fetched events to the store, a failing store doesn't fail the fetch
+func storeEvents(key string, events []Event) {
+	if eventStore == nil {
+		return
+	}
+
+	added, err := eventStore.Append(key, events)
+	if err != nil {
+		logger.Warn("Storing events failed", "key", key, "error", err)
+		return
+	}
+	logger.Debug("Events stored", "key", key, "added", added)
+}
+
+// Merge the stored events of a feed into the fetched ones, newest first
+func withStoredEvents(provider Provider, username string, events []Event) []Event {
+	if eventStore == nil {
+		return events
+	}
+
+	key := eventsCacheKey(provider, username)
+	stored, err := eventStore.Events(key)
+	if err != nil {
+		logger.Warn("Reading stored events failed", "key", key, "error", err)
+		return events
+	}
+
+	seen := make(map[string]bool, len(events))
+	merged := make([]Event, 0, len(events)+len(stored))
+	for _, list := range [][]Event{events, stored} {
+		for _, event := range list {
+			id := storedEventID(event)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			merged = append(merged, event)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+
+	return applyMutes(merged)
+}
+
+// Events without an ID, like the ones of some forges, are told apart by what
+// they are
+func storedEventID(event Event) string {
+	if event.ID != "" {
+		return event.Provider + "/" + event.ID
+	}
+
+	return event.Provider + "/" + event.Type + event.Repo.Name + event.CreatedAt.String()
+}
+
+// A line of the file store
+type storeRecord struct {
+	Key   string `json:"key"`
+	Event Event  `json:"event"`
+}
+
+// The file store appends one JSON record per line, the whole file is read
+// the first time it is used
+type fileStore struct {
+	path   string
+	mutex  sync.Mutex
+	loaded bool
+	events map[string][]Event
+	seen   map[string]bool
+}
+
+func newFileStore(path string) EventStore {
+	return &fileStore{path: path}
+}
+
+func (s *fileStore) load() error {
+	if s.loaded {
+		return nil
+	}
+	s.events = make(map[string][]Event)
+	s.seen = make(map[string]bool)
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.loaded = true
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record storeRecord
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", s.path, line, err)
+		}
+		s.add(record)
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	s.loaded = true
+
+	return nil
+}
+
+func (s *fileStore) add(record storeRecord) bool {
+	id := record.Key + "/" + storedEventID(record.Event)
+	if s.seen[id] {
+		return false
+	}
+	s.seen[id] = true
+	s.events[record.Key] = append(s.events[record.Key], record.Event)
+
+	return true
+}
+
+func (s *fileStore) Append(key string, events []Event) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	var lines []byte
+	var records []storeRecord
+	batch := make(map[string]bool)
+	for _, event := range events {
+		record := storeRecord{Key: key, Event: event}
+		id := key + "/" + storedEventID(event)
+		if s.seen[id] || batch[id] {
+			continue
+		}
+		batch[id] = true
+		line, err := json.Marshal(record)
+		if err != nil {
+			return 0, err
+		}
+		lines = append(append(lines, line...), '\n')
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	_, err = file.Write(lines)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Only written events are stored, the next append retries the others
+	for _, record := range records {
+		s.add(record)
+	}
+
+	return len(records), nil
+}
+
+func (s *fileStore) Events(key string) ([]Event, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	events := append([]Event(nil), s.events[key]...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+
+	return events, nil
+}
+
+func (s *fileStore) Prune(before time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make([]string, 0, len(s.events))
+	for key := range s.events {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var lines []byte
+	pruned := 0
+	for _, key := range keys {
+		for _, event := range s.events[key] {
+			if event.CreatedAt.Before(before) {
+				pruned++
+				continue
+			}
+			line, err := json.Marshal(storeRecord{Key: key, Event: event})
+			if err != nil {
+				return 0, err
+			}
+			lines = append(append(lines, line...), '\n')
+		}
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	// The kept events replace the file at once, a failed write keeps it whole
+	temporary, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(temporary.Name())
+
+	_, err = temporary.Write(lines)
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temporary.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(temporary.Name(), s.path)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Read the file again on the next use
+	s.loaded = false
+
+	return pruned, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// A run of days in a row, with or without activity
+type DayRun struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	Days int    `json:"days"`
+}
+
+// The streaks of a user over the days their events cover
+type StreakReport struct {
+	Username   string `json:"username"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	ActiveDays int    `json:"active_days"`
+	// Days in a row with activity up to today, or up to yesterday while
+	// today has none yet
+	Current DayRun `json:"current"`
+	Longest DayRun `json:"longest"`
+	// Runs of at least the gap length without activity, oldest first
+	Gaps []DayRun `json:"gaps"`
+}
+
+func computeStreaks(username string, days []ContributionDay, minGap int) StreakReport {
+	report := StreakReport{Username: username, Gaps: []DayRun{}}
+	if len(days) == 0 {
+		return report
+	}
+	report.From = days[0].Date
+	report.To = days[len(days)-1].Date
+
+	var run DayRun
+	for i, day := range days {
+		active := day.Count > 0
+		if active {
+			report.ActiveDays++
+		}
+		// Start a new run when the day isn't like the one before
+		if i == 0 || active != (days[i-1].Count > 0) {
+			run = DayRun{From: day.Date}
+		}
+		run.To = day.Date
+		run.Days++
+
+		last := i == len(days)-1 || active != (days[i+1].Count > 0)
+		if !last {
+			continue
+		}
+		if active && run.Days > report.Longest.Days {
+			report.Longest = run
+		}
+		if !active && run.Days >= minGap {
+			report.Gaps = append(report.Gaps, run)
+		}
+	}
+
+	// The streak is still alive while today has no activity yet
+	end := len(days) - 1
+	if days[end].Count == 0 && end > 0 {
+		end--
+	}
+	for i := end; i >= 0 && days[i].Count > 0; i-- {
+		report.Current = DayRun{From: days[i].Date, To: days[end].Date, Days: report.Current.Days + 1}
+	}
+
+	return report
+}
+
+func formatDayRun(run DayRun) string {
+	if run.Days == 0 {
+		return "0 days"
+	}
+	if run.From == run.To {
+		return fmt.Sprintf("1 day (%s)", run.From)
+	}
+
+	return fmt.Sprintf("%d days (%s to %s)", run.Days, run.From, run.To)
+}
+
+func printStreaks(report StreakReport, minGap int) {
+	fmt.Printf("User: %s\n", report.Username)
+	if report.From == "" {
+		fmt.Println("No activity")
+		return
+	}
+	fmt.Printf("From: %s\n", report.From)
+	fmt.Printf("To: %s\n", report.To)
+	fmt.Printf("Active Days: %d\n", report.ActiveDays)
+	fmt.Printf("Current Streak: %s\n", formatDayRun(report.Current))
+	fmt.Printf("Longest Streak: %s\n", formatDayRun(report.Longest))
+	if len(report.Gaps) == 0 {
+		fmt.Printf("Gaps of %d+ days: none\n", minGap)
+		return
+	}
+	fmt.Printf("Gaps of %d+ days:\n", minGap)
+	for _, gap := range report.Gaps {
+		fmt.Printf("  %s\n", formatDayRun(gap))
+	}
+}
+
+// streak <username> counts the days in a row with activity and lists the
+// gaps without any, over the fetched events and the stored ones
+func runStreak(args []string) {
+	flags := newFlagSet("streak")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	applyProvider := addProviderFlags(flags)
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	var types stringList
+	flags.Var(&types, "type", "only count events of this type, e.g. PushEvent, can be repeated or comma-separated (replaces the preset types)")
+	applyDateRange := addDateRangeFlags(flags)
+	applyFilters := addFilterFlags(flags)
+	pages := flags.Int("pages", github.MaxEventPages, "maximum number of pages to fetch")
+	gap := flags.Int("gap", 7, "list the runs of at least this many days without activity")
+	format := flags.String("format", "text", "output format: text or json")
+	applySource := addSourceFlag(flags)
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	applyStore := addStoreFlags(flags, "file")
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		log.Fatalf("Usage: streak [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 1y] [--pages n] [--gap 7] [--source events|graphql] [--format text|json] [--store none|file|sqlite] [--timeout 10s] [--deadline 2m] [--retries 3] <username>")
+	}
+	if *gap < 1 {
+		log.Fatalf("--gap must be at least 1")
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	providerConfig := ProviderConfig{Pages: *pages}
+	applyProvider(settings, &providerConfig)
+	applyTimeouts(settings)
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+	if len(types) > 0 {
+		filter.Types = types
+	}
+	applyDateRange(&filter)
+	applyFilters(&filter)
+	source := applySource(filter, *providerName)
+	provider, err := newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+	token := ""
+	if source == "graphql" {
+		token = graphQLToken(providerConfig.Token)
+	}
+
+	applyCache(settings)
+	applyStore(settings)
+	loadCache()
+
+	var reports []StreakReport
+	for _, username := range expandUsername(config, flags.Arg(0)) {
+		if source == "graphql" {
+			from, to := contributionPeriod(filter)
+			contributions, err := fetchContributionsBetween(token, username, from, to)
+			if err != nil {
+				exitIfInterrupted()
+				log.Fatalf("Error fetching contributions of %s: %v", username, err)
+			}
+			reports = append(reports, computeStreaks(username, contributions.Days(), *gap))
+			continue
+		}
+
+		events, err := getEvents(provider, username)
+		if err != nil {
+			exitIfInterrupted()
+			log.Fatalf("Error fetching events of %s: %v", username, err)
+		}
+		// The events API only goes 90 days back, the store what sync kept
+		events = withStoredEvents(provider, username, events)
+		events = filter.Apply(events)
+		days := eventsPerDay(events, filter.Since, graphEnd(filter))
+		reports = append(reports, computeStreaks(username, days, *gap))
+	}
+	saveCache()
+
+	if *format == "json" {
+		err = printJSON(reports)
+		if err != nil {
+			log.Fatalf("Error encoding streaks: %v", err)
+		}
+		return
+	}
+	for _, report := range reports {
+		printStreaks(report, *gap)
+		fmt.Println("----------------------")
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Event counts of a user, the summary command prints them
+type ActivitySummary struct {
+	Username string         `json:"username"`
+	Total    int            `json:"total"`
+	First    string         `json:"first,omitempty"`
+	Last     string         `json:"last,omitempty"`
+	PerType  map[string]int `json:"per_type"`
+	PerRepo  map[string]int `json:"per_repo"`
+	// Commits pushed in the window
+	Commits int `json:"commits"`
+	// Weekday and hour of the day, in local time, with the most events
+	BusiestDay        string `json:"busiest_day,omitempty"`
+	BusiestDayEvents  int    `json:"busiest_day_events,omitempty"`
+	BusiestHour       int    `json:"busiest_hour"`
+	BusiestHourEvents int    `json:"busiest_hour_events,omitempty"`
+}
+
+func summarizeEvents(username string, events []Event) ActivitySummary {
+	summary := ActivitySummary{
+		Username: username,
+		Total:    len(events),
+		PerType:  make(map[string]int),
+		PerRepo:  make(map[string]int),
+	}
+	var perWeekday [7]int
+	var perHour [24]int
+	for i, event := range events {
+		summary.PerType[event.Type]++
+		summary.PerRepo[event.Repo.Name]++
+		if event.Type == "PushEvent" {
+			commits := event.Size
+			if commits == 0 {
+				commits = len(event.Commits)
+			}
+			summary.Commits += commits
+		}
+		local := event.CreatedAt.Local()
+		perWeekday[local.Weekday()]++
+		perHour[local.Hour()]++
+
+		created := event.CreatedAt.Format("2006-01-02 15:04:05")
+		if i == 0 || created < summary.First {
+			summary.First = created
+		}
+		if created > summary.Last {
+			summary.Last = created
+		}
+	}
+
+	// Ties go to the earliest day of the week and hour
+	for day, count := range perWeekday {
+		if count > summary.BusiestDayEvents {
+			summary.BusiestDay = time.Weekday(day).String()
+			summary.BusiestDayEvents = count
+		}
+	}
+	for hour, count := range perHour {
+		if count > summary.BusiestHourEvents {
+			summary.BusiestHour = hour
+			summary.BusiestHourEvents = count
+		}
+	}
+
+	return summary
+}
+
+// Print the counts as a two column table, the largest first
+func printCountTable(w io.Writer, title string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintf(w, "%s\tEVENTS\n", title)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%d\n", key, counts[key])
+	}
+}
+
+func printSummary(summary ActivitySummary) {
+	fmt.Printf("User: %s\n", summary.Username)
+	fmt.Printf("Total Events: %d\n", summary.Total)
+	if summary.Total == 0 {
+		return
+	}
+	fmt.Printf("From: %s\n", summary.First)
+	fmt.Printf("To: %s\n", summary.Last)
+	fmt.Printf("Commits Pushed: %d\n", summary.Commits)
+	fmt.Printf("Busiest Day: %s (%d %s)\n", summary.BusiestDay, summary.BusiestDayEvents, plural(summary.BusiestDayEvents, "event"))
+	fmt.Printf("Busiest Hour: %02d:00-%02d:00 (%d %s)\n", summary.BusiestHour, (summary.BusiestHour+1)%24, summary.BusiestHourEvents, plural(summary.BusiestHourEvents, "event"))
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table)
+	printCountTable(table, "TYPE", summary.PerType)
+	fmt.Fprintln(table)
+	printCountTable(table, "REPOSITORY", summary.PerRepo)
+	table.Flush()
+}
+
+// summary <username> counts the recent events per type and repository, the
+// commits pushed and when the user is most active
+func runSummary(args []string) {
+	flags := newFlagSet("summary")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	applyProvider := addProviderFlags(flags)
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	var types stringList
+	flags.Var(&types, "type", "only show events of this type, e.g. PushEvent, can be repeated or comma-separated (replaces the preset types)")
+	applyDateRange := addDateRangeFlags(flags)
+	applyFilters := addFilterFlags(flags)
+	format := flags.String("format", "text", "output format: text, json or ics for an all-day calendar event per active day")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	applyStore := addStoreFlags(flags, "none")
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		log.Fatalf("Usage: summary [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--format text|json|ics] [--store file] [--timeout 10s] [--deadline 2m] [--retries 3] <username>")
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	var providerConfig ProviderConfig
+	applyProvider(settings, &providerConfig)
+	applyTimeouts(settings)
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+	if len(types) > 0 {
+		filter.Types = types
+	}
+	applyDateRange(&filter)
+	applyFilters(&filter)
+	provider, err := newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+
+	applyCache(settings)
+	applyStore(settings)
+	loadCache()
+
+	var summaries []ActivitySummary
+	var usernames []string
+	var eventsByUser [][]Event
+	for _, username := range expandUsername(config, flags.Arg(0)) {
+		events, err := getEvents(provider, username)
+		if err != nil {
+			exitIfInterrupted()
+			log.Fatalf("Error fetching events of %s: %v", username, err)
+		}
+		events = filter.Apply(withStoredEvents(provider, username, events))
+		summaries = append(summaries, summarizeEvents(username, events))
+		usernames = append(usernames, username)
+		eventsByUser = append(eventsByUser, events)
+	}
+
+	switch *format {
+	case "ics":
+		err = writeICalendarDays(os.Stdout, usernames, eventsByUser)
+		if err != nil {
+			log.Fatalf("Error writing ics: %v", err)
+		}
+	case "json":
+		err = printJSON(summaries)
+		if err != nil {
+			log.Fatalf("Error encoding summary: %v", err)
+		}
+	case "text":
+		for _, summary := range summaries {
+			printSummary(summary)
+			fmt.Println("----------------------")
+		}
+	default:
+		log.Fatalf("Unknown format %q, expected text, json or ics", *format)
+	}
+
+	saveCache()
+}
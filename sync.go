@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/febryansambuari/github-activity-cli/pkg/github"
+)
+
+// Fetch the events of a user newer than the newest stored one and store
+// them, returns the events added and how many are stored now
+func syncEvents(providerName string, config ProviderConfig, username string) ([]Event, int, error) {
+	provider, err := newProvider(providerName, config)
+	if err != nil {
+		return nil, 0, err
+	}
+	key := eventsCacheKey(provider, username)
+	stored, err := eventStore.Events(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(stored) > 0 {
+		config.StopAt = stored[0].ID
+		provider, err = newProvider(providerName, config)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	events, err := streamNormalizedEvents(provider, username, func(page []Event) {
+		fetchProgress.Page(provider.RateLimitInfo())
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Providers that can't stop at the stored events send some again
+	events = unseenEvents(events, eventIDs(stored))
+	_, err = eventStore.Append(key, events)
+	if err != nil {
+		return nil, 0, err
+	}
+	// The first sync of a user stores the history, none of it is new
+	if len(stored) == 0 {
+		return events, len(events), nil
+	}
+	unmuted := applyMutes(events)
+	notifyEvents(unmuted)
+	notifyDesktop(unmuted)
+
+	return events, len(stored) + len(events), nil
+}
+
+// sync <user>... fetches the events newer than the stored ones, for cron
+func runSync(args []string) {
+	flags := newFlagSet("sync")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	token := flags.String("token", "", "access token for the provider (defaults to GITHUB_TOKEN, GITLAB_TOKEN, GITEA_TOKEN or BITBUCKET_TOKEN)")
+	baseURL := flags.String("base-url", "", "base URL of a self-hosted provider instance")
+	pages := flags.Int("pages", github.MaxEventPages, "maximum number of pages to fetch, paging stops earlier at the stored events")
+	noProgress := flags.Bool("no-progress", false, "don't show the progress line on stderr")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyStore := addStoreFlags(flags, "file")
+	applyNotify := addNotifyFlag(flags)
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		log.Fatalf("Usage: sync [--provider name] [--token token] [--base-url url] [--pages n] [--store file|sqlite] [--store-path path] [--notify] <username>...")
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	err = settings.Resolve("provider", "GITHUB_ACTIVITY_PROVIDER", "defaults.provider")
+	if err != nil {
+		log.Fatalf("Error resolving settings: %v", err)
+	}
+	envPrefix := providerEnvPrefix(*providerName)
+	for _, resolveErr := range []error{
+		settings.ResolveSecret("token", envPrefix+"_TOKEN", *providerName+".token"),
+		settings.Resolve("base-url", envPrefix+"_BASE_URL", *providerName+".base_url"),
+	} {
+		if resolveErr != nil {
+			log.Fatalf("Error resolving settings: %v", resolveErr)
+		}
+	}
+	applyTimeouts(settings)
+	applyStore(settings)
+	applyNotify(config)
+	if eventStore == nil {
+		log.Fatalf("sync keeps the events in the store, --store can't be none")
+	}
+
+	var usernames []string
+	for _, arg := range flags.Args() {
+		usernames = append(usernames, expandUsername(config, arg)...)
+	}
+
+	cancelOnInterrupt()
+	if !*noProgress {
+		fetchProgress.Start(len(usernames))
+	}
+	providerConfig := ProviderConfig{Token: *token, BaseURL: *baseURL, Pages: *pages}
+	failed := 0
+	for _, username := range usernames {
+		if interrupted.Err() != nil {
+			break
+		}
+		events, total, err := syncEvents(*providerName, providerConfig, username)
+		fetchProgress.UserDone()
+		if err != nil {
+			fetchProgress.Clear()
+			logger.Error("Syncing events failed", "user", username, "error", err)
+			failed++
+			continue
+		}
+		fetchProgress.Clear()
+		fmt.Printf("%s: %d new events, %d stored\n", username, len(events), total)
+	}
+	fetchProgress.Stop()
+
+	// The events of the users synced so far are stored, sync doesn't use
+	// the cache so there is nothing else to save
+	if interrupted.Err() != nil {
+		os.Exit(130)
+	}
+	if failed > 0 {
+		log.Fatalf("Syncing failed for %d of %d users", failed, len(usernames))
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// Print the events of a user oldest first, then poll the provider and print
+// every event that wasn't seen before at the bottom, like tail -f
+func followEvents(provider Provider, username string, interval time.Duration, filter EventFilter) {
+	events, err := getEvents(provider, username)
+	if err != nil {
+		exitIfInterrupted()
+		log.Fatalf("Error fetching events: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	// The events of the first fetch aren't new, they aren't posted
+	printNewEvents(filter.Apply(events), seen)
+
+	// Stop hammering the provider while it keeps failing
+	breaker := newCircuitBreaker()
+	for {
+		select {
+		case <-time.After(pollWait(provider, interval)):
+		case <-interrupted.Done():
+		}
+		exitIfInterrupted()
+		if !breaker.Allow() {
+			continue
+		}
+
+		events, err = refreshEvents(provider, username)
+		if err != nil {
+			exitIfInterrupted()
+			// Keep following, the next poll may succeed
+			logger.Error("Fetching events failed", "user", username, "error", err)
+			if breaker.Failure(interval) {
+				logger.Warn("Circuit breaker " + breaker.String())
+			}
+			continue
+		}
+		if breaker.Success() {
+			logger.Warn("Circuit breaker closed, polling again", "interval", interval.String())
+		}
+		newEvents := printNewEvents(filter.Apply(events), seen)
+		notifyEvents(newEvents)
+		notifyDesktop(newEvents)
+	}
+}
+
+// The interval, or longer when the provider asked to be polled less often
+func pollWait(provider Provider, interval time.Duration) time.Duration {
+	if polling, ok := provider.(PollingProvider); ok && polling.PollInterval() > interval {
+		return polling.PollInterval()
+	}
+
+	return interval
+}
+
+// Print the events that weren't seen before, oldest first, and return them
+func printNewEvents(events []Event, seen map[string]bool) []Event {
+	var newEvents []Event
+	for _, event := range events {
+		key := event.ID
+		if key == "" {
+			key = event.Type + event.Repo.Name + event.CreatedAt.String()
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		newEvents = append(newEvents, event)
+	}
+
+	sort.SliceStable(newEvents, func(i, j int) bool {
+		return newEvents[i].CreatedAt.Before(newEvents[j].CreatedAt)
+	})
+	renderer := newEventRenderer(os.Stdout)
+	for _, event := range newEvents {
+		renderer.Line(event)
+	}
+
+	return newEvents
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// The merged activity of the members of a list alias
+type TeamActivity struct {
+	Team    string   `json:"team"`
+	Members []string `json:"members"`
+	// Counts of every member together, under the name of the team
+	Summary ActivitySummary `json:"summary"`
+	// Events and commits pushed per member
+	PerMember map[string]int `json:"per_member"`
+	Commits   map[string]int `json:"commits_per_member"`
+	// Events of every member, newest first
+	Events []UserEventLine `json:"events"`
+}
+
+func teamActivity(team string, results []UserEvents, filter EventFilter) TeamActivity {
+	activity := TeamActivity{
+		Team:      team,
+		PerMember: make(map[string]int),
+		Commits:   make(map[string]int),
+		Events:    []UserEventLine{},
+	}
+	var events []Event
+	for _, result := range results {
+		activity.Members = append(activity.Members, result.Username)
+		if result.Err != nil {
+			continue
+		}
+
+		memberEvents := filter.ApplyLimit(result.Events)
+		activity.PerMember[result.Username] = len(memberEvents)
+		activity.Commits[result.Username] = summarizeEvents(result.Username, memberEvents).Commits
+		for i := range memberEvents {
+			activity.Events = append(activity.Events, UserEventLine{Username: result.Username, Event: &memberEvents[i]})
+		}
+		events = append(events, memberEvents...)
+	}
+	sort.SliceStable(activity.Events, func(i, j int) bool {
+		return activity.Events[i].Event.CreatedAt.After(activity.Events[j].Event.CreatedAt)
+	})
+	activity.Summary = summarizeEvents(team, events)
+
+	return activity
+}
+
+func printTeamActivity(activity TeamActivity) {
+	fmt.Printf("Team: %s (%s)\n", activity.Team, strings.Join(activity.Members, ", "))
+	fmt.Printf("Total Events: %d\n", activity.Summary.Total)
+	fmt.Printf("Commits Pushed: %d\n", activity.Summary.Commits)
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table)
+	fmt.Fprintf(table, "MEMBER\tEVENTS\tCOMMITS\n")
+	for _, member := range activity.Members {
+		fmt.Fprintf(table, "%s\t%d\t%d\n", member, activity.PerMember[member], activity.Commits[member])
+	}
+	fmt.Fprintln(table)
+	printCountTable(table, "TYPE", activity.Summary.PerType)
+	fmt.Fprintln(table)
+	printCountTable(table, "REPOSITORY", activity.Summary.PerRepo)
+	table.Flush()
+
+	if len(activity.Events) == 0 {
+		return
+	}
+	// One line per event, attributed to the member whose feed it came from
+	fmt.Println("----------------------")
+	width := 0
+	for _, member := range activity.Members {
+		if len(member) > width {
+			width = len(member)
+		}
+	}
+	location := displayLocation
+	if location == nil {
+		location = time.Local
+	}
+	renderer := newEventRenderer(os.Stdout)
+	for _, line := range activity.Events {
+		created := renderer.paint(ansiDim, line.Event.CreatedAt.In(location).Format("01-02 15:04"))
+		fmt.Printf("%s  %-*s  %s\n", created, width, line.Username, renderer.sentence(*line.Event))
+	}
+}
+
+// team <alias> fetches every member of a list alias and prints their merged
+// events with a summary of the whole team
+func runTeam(args []string) {
+	flags := newFlagSet("team")
+	providerName := flags.String("provider", "github", "forge to fetch the activity from: "+strings.Join(providerNames(), ", "))
+	applyProvider := addProviderFlags(flags)
+	preset := flags.String("preset", "", "apply a filter preset defined in the config file")
+	var types stringList
+	flags.Var(&types, "type", "only show events of this type, e.g. PushEvent, can be repeated or comma-separated (replaces the preset types)")
+	applyDateRange := addDateRangeFlags(flags)
+	applyFilters := addFilterFlags(flags)
+	limit := flags.Int("limit", 0, "maximum number of events per member")
+	format := flags.String("format", "text", "output format: text or json")
+	concurrency := flags.Int("concurrency", 4, "number of members fetched at the same time")
+	noProgress := flags.Bool("no-progress", false, "don't show the progress line on stderr")
+	applyTimeDisplay := addTimeDisplayFlags(flags)
+	applyTimeouts := addTimeoutFlags(flags)
+	applyCache := addCacheFlags(flags)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Usage: team [--provider name] [--token token] [--base-url url] [--preset name] [--type type] [--since 7d] [--until 2024-01-31] [--limit n] [--format text|json] [--timeout 10s] [--deadline 2m] [--retries 3] <alias>")
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+	if *concurrency < 1 {
+		log.Fatalf("--concurrency must be at least 1")
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	team := flags.Arg(0)
+	members, ok := config["aliases."+team].([]string)
+	if !ok || len(members) == 0 {
+		log.Fatalf("No team %s, define it as a list alias in %s: [aliases] %s = [\"alice\", \"bob\"]", team, configPath(), team)
+	}
+	members = uniqueStrings(members)
+
+	filter, err := filterFromPreset(config, *preset)
+	if err != nil {
+		log.Fatalf("Error applying preset: %v", err)
+	}
+	if len(types) > 0 {
+		filter.Types = types
+	}
+	applyDateRange(&filter)
+	applyFilters(&filter)
+	filter.Limit = *limit
+	settings := newSettingsResolver(flags, config)
+	providerConfig := ProviderConfig{Limit: *limit}
+	applyProvider(settings, &providerConfig)
+	applyTimeouts(settings)
+	_, err = newProvider(*providerName, providerConfig)
+	if err != nil {
+		log.Fatalf("Error configuring provider: %v", err)
+	}
+
+	applyCache(settings)
+	applyTimeDisplay(settings)
+	loadCache()
+	err = loadArchive()
+	if err != nil {
+		log.Fatalf("Error loading archive: %v", err)
+	}
+	cancelOnInterrupt()
+
+	if !*noProgress {
+		fetchProgress.Start(len(members))
+	}
+	results := fetchUsersEvents(func() (Provider, error) {
+		return newProvider(*providerName, providerConfig)
+	}, members, *concurrency)
+	fetchProgress.Stop()
+	saveCache()
+	exitIfInterrupted()
+
+	// A member that failed is reported and left out of the counts
+	failed := false
+	for _, result := range results {
+		if result.Err != nil {
+			logger.Error("Fetching events failed", "user", result.Username, "error", result.Err)
+			failed = true
+		}
+	}
+
+	activity := teamActivity(team, results, filter)
+	startPager()
+	if *format == "json" {
+		err = printJSON(activity)
+		if err != nil {
+			log.Fatalf("Error encoding team activity: %v", err)
+		}
+	} else {
+		printTeamActivity(activity)
+	}
+	stopPager()
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Set by --template, events are printed with it instead of the text blocks
+var eventTemplate *template.Template
+
+// What an event template can use: every field of the event, e.g.
+// {{.Repo.Name}} or {{.Target.Title}}, with the sentence and links the text
+// output shows and the payload the provider sent
+type TemplateEvent struct {
+	Event
+	Sentence string
+	URL      string
+	RepoURL  string
+	// The payload of a Github event, or the whole event as the other
+	// providers sent it, decoded as JSON: {{.Payload.pull_request.title}}
+	Payload interface{}
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Parse the value of --template, the path of a file holding the template or
+// the template itself
+func parseEventTemplate(value string) (*template.Template, error) {
+	text := value
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		content, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		text = string(content)
+	}
+
+	return template.New("event").Funcs(templateFuncs).Parse(text)
+}
+
+func newTemplateEvent(event Event) TemplateEvent {
+	var raw map[string]interface{}
+	_ = json.Unmarshal(event.Raw, &raw)
+	var payload interface{} = raw
+	if isGithubEvent(event) && raw["payload"] != nil {
+		payload = raw["payload"]
+	}
+
+	return TemplateEvent{
+		Event:    event,
+		Sentence: describeEvent(event),
+		URL:      eventHTMLURL(event),
+		RepoURL:  repoHTMLURL(event),
+		Payload:  payload,
+	}
+}
+
+// Print an event with the template, on its own line unless the template ends
+// with a newline already
+func (r *eventRenderer) Template(event Event) {
+	var b strings.Builder
+	err := eventTemplate.Execute(&b, newTemplateEvent(event))
+	if err != nil {
+		logger.Warn("Executing template failed", "event", event.ID, "error", err)
+		return
+	}
+
+	line := b.String()
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	fmt.Fprint(r.w, line)
+}
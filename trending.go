@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+type GithubRepository struct {
+	FullName        string    `json:"full_name"`
+	Description     string    `json:"description"`
+	HTMLURL         string    `json:"html_url"`
+	Language        string    `json:"language"`
+	StargazersCount int       `json:"stargazers_count"`
+	ForksCount      int       `json:"forks_count"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type GithubSearchRepositoriesResponse struct {
+	TotalCount int                `json:"total_count"`
+	Items      []GithubRepository `json:"items"`
+}
+
+// Map the --since values to how far back a repository may have been created
+var trendingPeriods = map[string]time.Duration{
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+}
+
+// Github has no trending API, so approximate it by searching the repositories
+// created within the period and ordering them by stars
+func getTrendingRepositories(language string, since string) ([]GithubRepository, error) {
+	period, ok := trendingPeriods[since]
+	if !ok {
+		return nil, fmt.Errorf("invalid since value %q, expected daily, weekly or monthly", since)
+	}
+
+	query := fmt.Sprintf("created:>=%s", time.Now().Add(-period).Format("2006-01-02"))
+	if language != "" {
+		query += fmt.Sprintf(" language:%s", language)
+	}
+
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("sort", "stars")
+	params.Set("order", "desc")
+
+	githubUrl := "search/repositories?" + params.Encode()
+	var searchResponse GithubSearchRepositoriesResponse
+	err := getGithubJSON(githubUrl, &searchResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchResponse.Items, nil
+}
+
+func runTrending(args []string) {
+	flags := newFlagSet("trending")
+	language := flags.String("language", "", "only show repositories written in this language")
+	since := flags.String("since", "daily", "trending period: daily, weekly or monthly")
+	format := flags.String("format", "text", "output format: text or json")
+	applyTimeouts := addTimeoutFlags(flags)
+	applyToken := addGithubTokenFlag(flags)
+	_ = flags.Parse(args)
+	applyToken()
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	settings := newSettingsResolver(flags, config)
+	applyTimeouts(settings)
+
+	repositories, err := getTrendingRepositories(*language, *since)
+	if err != nil {
+		exitIfInterrupted()
+		log.Fatalf("Error fetching trending repositories: %v", err)
+		return
+	}
+
+	switch *format {
+	case "json":
+		err = printJSON(repositories)
+		if err != nil {
+			log.Fatalf("Error encoding trending repositories: %v", err)
+		}
+	case "text":
+		for _, repository := range repositories {
+			fmt.Printf("Repo Name: %s\n", repository.FullName)
+			fmt.Printf("Description: %s\n", repository.Description)
+			fmt.Printf("Language: %s\n", repository.Language)
+			fmt.Printf("Stars: %d\n", repository.StargazersCount)
+			fmt.Printf("Forks: %d\n", repository.ForksCount)
+			fmt.Printf("Repo URL: %s\n", repository.HTMLURL)
+			fmt.Println("----------------------")
+		}
+	default:
+		log.Fatalf("Unknown format %q, expected text or json", *format)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// A full screen terminal session. Raw mode is toggled through stty so the
+// tool keeps working without any terminal library.
+type terminal struct {
+	tty   *os.File
+	state string
+}
+
+func openTerminal() (*terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := stty(tty, "-g")
+	if err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("reading terminal state: %v", err)
+	}
+
+	_, err = stty(tty, "raw", "-echo")
+	if err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("switching terminal to raw mode: %v", err)
+	}
+
+	// Switch to the alternate screen and hide the cursor
+	fmt.Fprint(tty, "\x1b[?1049h\x1b[?25l")
+
+	return &terminal{tty: tty, state: strings.TrimSpace(state)}, nil
+}
+
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+// Restore the screen and the terminal mode
+func (t *terminal) Close() {
+	fmt.Fprint(t.tty, "\x1b[?25h\x1b[?1049l")
+	_, _ = stty(t.tty, t.state)
+	t.tty.Close()
+}
+
+// Size returns the number of rows and columns, falling back to 24x80
+func (t *terminal) Size() (int, int) {
+	output, err := stty(t.tty, "size")
+	if err != nil {
+		return 24, 80
+	}
+
+	var rows, cols int
+	_, err = fmt.Sscanf(output, "%d %d", &rows, &cols)
+	if err != nil || rows == 0 || cols == 0 {
+		return 24, 80
+	}
+
+	return rows, cols
+}
+
+// Draw replaces the screen with the given lines
+func (t *terminal) Draw(lines []string) {
+	var frame strings.Builder
+	frame.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			frame.WriteString("\r\n")
+		}
+		frame.WriteString(line)
+		frame.WriteString("\x1b[K")
+	}
+	frame.WriteString("\x1b[J")
+	fmt.Fprint(t.tty, frame.String())
+}
+
+// Read key presses and send them as names ("up", "enter", "q", ...) until
+// the terminal is closed
+func (t *terminal) ReadKeys(keys chan<- string) {
+	buffer := make([]byte, 32)
+	for {
+		n, err := t.tty.Read(buffer)
+		if err != nil {
+			close(keys)
+			return
+		}
+
+		input := buffer[:n]
+		for len(input) > 0 {
+			key, size := parseKey(input)
+			keys <- key
+			input = input[size:]
+		}
+	}
+}
+
+func parseKey(input []byte) (string, int) {
+	if input[0] == 0x1b {
+		if len(input) >= 3 && input[1] == '[' {
+			switch input[2] {
+			case 'A':
+				return "up", 3
+			case 'B':
+				return "down", 3
+			case 'C':
+				return "right", 3
+			case 'D':
+				return "left", 3
+			}
+			return "", 3
+		}
+		return "esc", 1
+	}
+
+	switch input[0] {
+	case '\r', '\n':
+		return "enter", 1
+	case 0x7f, 0x08:
+		return "backspace", 1
+	case 0x03:
+		return "ctrl+c", 1
+	case '\t':
+		return "tab", 1
+	}
+
+	r, size := utf8.DecodeRune(input)
+	return string(r), size
+}
+
+// Cut or pad s to exactly width runes
+func fitWidth(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	runes := []rune(s)
+	if len(runes) > width {
+		if width == 1 {
+			return "…"
+		}
+		return string(runes[:width-1]) + "…"
+	}
+
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+func reverseVideo(s string) string {
+	return "\x1b[7m" + s + "\x1b[0m"
+}
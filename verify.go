@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Verification states of a commit signature, any other value is the reason
+// Github gave for rejecting the signature
+const (
+	CommitVerified = "verified"
+	CommitUnsigned = "unsigned"
+)
+
+type githubCommitVerification struct {
+	Commit struct {
+		Verification struct {
+			Verified bool   `json:"verified"`
+			Reason   string `json:"reason"`
+		} `json:"verification"`
+	} `json:"commit"`
+}
+
+// Signatures never change, so each commit is only checked once per run
+var verificationCache = make(map[string]string)
+var verificationMutex sync.Mutex
+
+// Whether the GPG or SSH signature of a commit is verified by Github
+func commitVerification(repo string, sha string) (string, error) {
+	key := repo + "@" + sha
+	verificationMutex.Lock()
+	status, ok := verificationCache[key]
+	verificationMutex.Unlock()
+	if ok {
+		return status, nil
+	}
+
+	var response githubCommitVerification
+	err := getGithubJSON(fmt.Sprintf("repos/%s/commits/%s", repo, sha), &response)
+	if err != nil {
+		return "", err
+	}
+
+	verification := response.Commit.Verification
+	switch {
+	case verification.Verified:
+		status = CommitVerified
+	case verification.Reason == "unsigned":
+		status = CommitUnsigned
+	default:
+		status = verification.Reason
+	}
+
+	verificationMutex.Lock()
+	verificationCache[key] = status
+	verificationMutex.Unlock()
+
+	return status, nil
+}
+
+// Fill in the signature verification of the commits of Github push events,
+// commits that can't be checked are left without one
+func verifyEventCommits(events []Event) []Event {
+	verified := make([]Event, 0, len(events))
+	for _, event := range events {
+		if isGithubEvent(event) && event.Type == "PushEvent" && len(event.Commits) > 0 {
+			commits := make([]EventCommit, len(event.Commits))
+			copy(commits, event.Commits)
+			for i, commit := range commits {
+				status, err := commitVerification(event.Repo.Name, commit.SHA)
+				if err != nil {
+					logger.Warn("Checking the signature of a commit failed", "sha", commit.SHA, "error", err)
+					continue
+				}
+				commits[i].Verification = status
+			}
+			event.Commits = commits
+		}
+		verified = append(verified, event)
+	}
+
+	return verified
+}
+
+func hasUnverifiedCommit(event Event) bool {
+	for _, commit := range event.Commits {
+		if commit.Verification != "" && commit.Verification != CommitVerified {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The abbreviated SHA Github shows
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+
+	return sha
+}
+
+// Short commit line with its signature state when it was checked
+func formatCommit(commit EventCommit) string {
+	line := fmt.Sprintf("Commit %s: %s", shortSHA(commit.SHA), strings.SplitN(commit.Message, "\n", 2)[0])
+	switch commit.Verification {
+	case "":
+	case CommitVerified:
+		line += " [✓ verified]"
+	case CommitUnsigned:
+		line += " [✗ unsigned]"
+	default:
+		line += " [✗ unverified: " + commit.Verification + "]"
+	}
+
+	return line
+}